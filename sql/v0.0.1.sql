@@ -49,3 +49,279 @@ create index wallet_address_token_index
 ALTER TABLE `wallet_address` CHANGE `token` `token` VARCHAR(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '钱包token';
 ALTER TABLE `orders` CHANGE `token` `token` VARCHAR(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL COMMENT '所属钱包地址';
 ALTER TABLE `orders` DROP `start_block`;
+
+-- 20261019 钱包分配策略
+
+ALTER TABLE `wallet_address` ADD `weight` INT NOT NULL DEFAULT 1 COMMENT '权重，按权重分配时使用' AFTER `channel`;
+ALTER TABLE `wallet_address` ADD `daily_volume_limit` DECIMAL(19, 4) NOT NULL DEFAULT 0 COMMENT '每日收款金额上限，0为不限制' AFTER `weight`;
+ALTER TABLE `wallet_address` ADD `daily_order_limit` INT NOT NULL DEFAULT 0 COMMENT '每日订单数上限，0为不限制' AFTER `daily_volume_limit`;
+CREATE INDEX orders_token_created_at_index ON orders (token, created_at);
+
+-- 20261019 钱包余额监控
+
+create table wallet_balance
+(
+    id                int auto_increment
+        primary key,
+    wallet_id         int                       not null comment '钱包id',
+    token             varchar(100)              not null comment '钱包地址（带有链前缀）',
+    channel           varchar(10)               not null comment '链类',
+    usdt_balance      decimal(30, 6) default 0  not null comment 'usdt余额',
+    native_balance    decimal(36, 18) default 0 not null comment '原生代币(gas)余额',
+    received_amount   decimal(30, 6) default 0  not null comment '对账窗口内链上收到的usdt',
+    paid_order_amount decimal(30, 6) default 0  not null comment '对账窗口内对应支付成功订单的金额',
+    created_at        timestamp                 null,
+    updated_at        timestamp                 null,
+    deleted_at        timestamp                 null
+)
+    comment '钱包余额快照';
+
+create index wallet_balance_wallet_id_created_at_index
+    on wallet_balance (wallet_id, created_at);
+
+-- 20261019 钱包标签
+
+ALTER TABLE `wallet_address` ADD `label` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '标签' AFTER `daily_order_limit`;
+ALTER TABLE `wallet_address` ADD `note` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '备注' AFTER `label`;
+ALTER TABLE `wallet_address` ADD `group_name` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '所属分组/负责人' AFTER `note`;
+ALTER TABLE `wallet_address` ADD `checkout_message` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '收银台提示信息' AFTER `group_name`;
+create index wallet_address_group_name_index
+    on wallet_address (group_name);
+
+-- 20261019 金额精度最大支持6位小数
+
+ALTER TABLE `orders` CHANGE `actual_amount` `actual_amount` DECIMAL(19, 6) NOT NULL COMMENT '订单实际需要支付的金额，最多保留6位小数';
+
+-- 20261019 定价规则
+
+create table pricing_rule
+(
+    id             int auto_increment
+        primary key,
+    channel        varchar(10)  default ''        not null comment '链类，为空匹配所有链',
+    currency       varchar(10)  default ''        not null comment '法币，为空匹配所有法币',
+    markup_percent decimal(10, 4) default 0      not null comment '加价百分比，负数为折扣',
+    fixed_fee      decimal(19, 6) default 0      not null comment '固定手续费(usdt)',
+    rounding       varchar(16)  default 'half_up' not null comment '舍入方式 half_up up down half_even',
+    min_amount     decimal(19, 4) default 0      not null comment '最小订单金额(法币)，0为不限制',
+    max_amount     decimal(19, 4) default 0      not null comment '最大订单金额(法币)，0为不限制',
+    status         int          default 1         not null comment '1:启用 2:禁用',
+    created_at     timestamp                      null,
+    updated_at     timestamp                      null,
+    deleted_at     timestamp                      null
+)
+    comment '定价规则';
+
+create index pricing_rule_channel_currency_index
+    on pricing_rule (channel, currency);
+
+ALTER TABLE `orders` ADD `currency` VARCHAR(10) NOT NULL DEFAULT '' COMMENT '订单金额的法币' AFTER `callback_confirm`;
+ALTER TABLE `orders` ADD `raw_rate` DECIMAL(19, 6) NOT NULL DEFAULT 0 COMMENT '下单时的原始汇率' AFTER `currency`;
+ALTER TABLE `orders` ADD `pricing_rule_id` INT NOT NULL DEFAULT 0 COMMENT '应用的定价规则id，0为未应用' AFTER `raw_rate`;
+ALTER TABLE `orders` ADD `markup_percent` DECIMAL(10, 4) NOT NULL DEFAULT 0 COMMENT '应用的加价百分比' AFTER `pricing_rule_id`;
+ALTER TABLE `orders` ADD `fixed_fee` DECIMAL(19, 6) NOT NULL DEFAULT 0 COMMENT '应用的固定手续费(usdt)' AFTER `markup_percent`;
+ALTER TABLE `orders` ADD `rounding` VARCHAR(16) NOT NULL DEFAULT '' COMMENT '应用的舍入方式' AFTER `fixed_fee`;
+
+-- 20261019 汇率快照与汇率历史
+
+ALTER TABLE `orders` ADD `rate_source` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '汇率来源 request forced median:汇率源' AFTER `raw_rate`;
+ALTER TABLE `orders` ADD `rate_at` TIMESTAMP NULL COMMENT '汇率的更新时间' AFTER `rate_source`;
+
+create table usdt_rate_history
+(
+    id         int auto_increment
+        primary key,
+    currency   varchar(10)    not null comment '法币',
+    rate       decimal(19, 6) not null comment '聚合后的汇率，1 USDT 可兑换的法币数量',
+    source     varchar(255)   not null comment '汇率来源',
+    quotes     text           null comment '参与聚合的各汇率源报价(json)',
+    created_at timestamp      null,
+    updated_at timestamp      null,
+    deleted_at timestamp      null
+)
+    comment '汇率历史';
+
+create index usdt_rate_history_currency_created_at_index
+    on usdt_rate_history (currency, created_at);
+
+-- 20261019 切换付款网络
+
+ALTER TABLE `orders` ADD `expired_at` TIMESTAMP NULL COMMENT '过期时间，切换付款网络时重置' AFTER `rounding`;
+
+create table order_channel_history
+(
+    id                 int auto_increment
+        primary key,
+    trade_id           varchar(32)              not null comment 'epusdt订单号',
+    from_token         varchar(100) default ''  not null comment '切换前的钱包地址（带有链前缀）',
+    from_actual_amount decimal(19, 6) default 0 not null comment '切换前需要支付的金额',
+    from_expired_at    timestamp                null comment '切换前的钱包金额占用到期时间，到期前向原地址的转账仍可匹配订单',
+    to_token           varchar(100)             not null comment '切换后的钱包地址（带有链前缀）',
+    to_actual_amount   decimal(19, 6)           not null comment '切换后需要支付的金额',
+    source             varchar(16)              not null comment '切换来源 api checkout',
+    created_at         timestamp                null,
+    updated_at         timestamp                null,
+    deleted_at         timestamp                null
+)
+    comment '订单付款网络切换记录';
+
+create index order_channel_history_trade_id_index
+    on order_channel_history (trade_id);
+
+-- 20261019 回调投递记录
+
+create table callback_log
+(
+    id            int auto_increment
+        primary key,
+    trade_id      varchar(32)             not null comment 'epusdt订单号',
+    order_id      varchar(32)             not null comment '客户交易id',
+    attempt       int          default 1  not null comment '第几次回调',
+    notify_url    varchar(255)            not null comment '回调地址',
+    payload       text                    null comment '请求内容',
+    signature     varchar(255) default '' not null comment '签名',
+    status_code   int          default 0  not null comment 'http状态码，请求失败为0',
+    response_body text                    null comment '响应内容，超出部分截断',
+    duration      int          default 0  not null comment '耗时(毫秒)',
+    error         text                    null comment '错误信息',
+    status        int          default 2  not null comment '1：成功 2：失败',
+    created_at    timestamp               null,
+    updated_at    timestamp               null,
+    deleted_at    timestamp               null
+)
+    comment '异步回调投递记录';
+
+create index callback_log_trade_id_index
+    on callback_log (trade_id);
+
+create index callback_log_order_id_index
+    on callback_log (order_id);
+
+-- 20261019 回调重试失败状态
+
+ALTER TABLE `orders` MODIFY `callback_confirm` int default 2 null comment '回调是否已确认？ 1是 2否 3重试全部失败';
+
+-- 20261019 手动重新回调
+
+create table callback_resend_log
+(
+    id                    int auto_increment
+        primary key,
+    trade_id              varchar(32)            not null comment 'epusdt订单号',
+    operator              varchar(64)            not null comment '操作人',
+    source                varchar(16)            not null comment '操作来源 api telegram',
+    prev_callback_num     int         default 0  not null comment '重新回调前的回调次数',
+    prev_callback_confirm int         default 2  not null comment '重新回调前的回调状态',
+    created_at            timestamp              null,
+    updated_at            timestamp              null,
+    deleted_at            timestamp              null
+)
+    comment '手动重新回调记录';
+
+create index callback_resend_log_trade_id_index
+    on callback_resend_log (trade_id);
+
+-- 20261019 订单状态事件回调
+
+ALTER TABLE `orders` ADD `notify_events` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '额外开启回调的事件，逗号分隔' AFTER `expired_at`;
+ALTER TABLE `orders` MODIFY `status` int default 1 not null comment '1：等待支付，2：支付成功，3：已过期，4：已取消';
+ALTER TABLE `callback_log` ADD `event_type` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '事件类型' AFTER `order_id`;
+
+-- 20261019 多api密钥
+
+create table api_key
+(
+    id         int auto_increment
+        primary key,
+    key_id     varchar(32)            not null comment '密钥id',
+    secret     varchar(64)            not null comment '签名密钥',
+    name       varchar(64) default '' not null comment '名称',
+    scopes     varchar(64)            not null comment '权限，逗号分隔 create query cancel admin',
+    status     int         default 1  not null comment '1：启用 2：禁用',
+    expired_at timestamp              null comment '过期时间，为空永不过期',
+    created_at timestamp              null,
+    updated_at timestamp              null,
+    deleted_at timestamp              null,
+    constraint api_key_key_id_uindex
+        unique (key_id)
+)
+    comment 'api密钥';
+
+ALTER TABLE `orders` ADD `api_key_id` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '创建订单的api密钥id，回调使用该密钥签名' AFTER `notify_events`;
+
+-- 20261019 多商户
+
+create table merchant
+(
+    id            int auto_increment
+        primary key,
+    name          varchar(64)              not null comment '名称',
+    status        int          default 1   not null comment '1：启用 2：禁用',
+    notify_url    varchar(255) default ''  not null comment '默认异步回调地址',
+    notify_events varchar(128) default ''  not null comment '默认额外开启回调的事件，逗号分隔，为空使用全局配置',
+    tg_chat_ids   varchar(255) default ''  not null comment '接收该商户通知的telegram会话id，逗号分隔',
+    created_at    timestamp                null,
+    updated_at    timestamp                null,
+    deleted_at    timestamp                null
+)
+    comment '商户';
+
+ALTER TABLE `orders` ADD `merchant_id` INT NOT NULL DEFAULT 0 COMMENT '所属商户id，0为默认商户' AFTER `id`;
+ALTER TABLE `orders` DROP INDEX `orders_order_id_uindex`, ADD UNIQUE INDEX `orders_merchant_id_order_id_uindex` (`merchant_id`, `order_id`);
+ALTER TABLE `wallet_address` ADD `merchant_id` INT NOT NULL DEFAULT 0 COMMENT '所属商户id，0为默认商户' AFTER `id`;
+ALTER TABLE `pricing_rule` ADD `merchant_id` INT NOT NULL DEFAULT 0 COMMENT '所属商户id，0为默认商户' AFTER `id`;
+ALTER TABLE `api_key` ADD `merchant_id` INT NOT NULL DEFAULT 0 COMMENT '所属商户id，0为默认商户' AFTER `id`;
+ALTER TABLE `callback_log` ADD `merchant_id` INT NOT NULL DEFAULT 0 COMMENT '所属商户id' AFTER `id`;
+create index callback_log_merchant_id_index
+    on callback_log (merchant_id);
+
+-- 20261019 平台手续费
+
+create table fee_rule
+(
+    id          int auto_increment
+        primary key,
+    merchant_id int            default 0  not null comment '所属商户id，0为默认商户',
+    channel     varchar(32)    default '' not null comment '链类，为空匹配所有链',
+    percent     decimal(10, 4) default 0  not null comment '按实收金额计提的百分比',
+    fixed_fee   decimal(19, 4) default 0  not null comment '每笔固定手续费(usdt)',
+    status      int            default 1  not null comment '1:启用 2:禁用',
+    created_at  timestamp                 null,
+    updated_at  timestamp                 null,
+    deleted_at  timestamp                 null
+)
+    comment '平台手续费规则';
+
+create index fee_rule_merchant_id_index
+    on fee_rule (merchant_id);
+
+create table fee_ledger
+(
+    id           int auto_increment
+        primary key,
+    merchant_id  int            default 0  not null comment '所属商户id',
+    trade_id     varchar(32)               not null comment 'epusdt订单号',
+    order_id     varchar(32)               not null comment '客户交易id',
+    channel      varchar(32)               not null comment '收款链',
+    fee_rule_id  int            default 0  not null comment '应用的手续费规则id，0为未匹配规则',
+    percent      decimal(10, 4) default 0  not null comment '应用的百分比',
+    fixed_fee    decimal(19, 4) default 0  not null comment '应用的固定手续费(usdt)',
+    gross_amount decimal(19, 6) default 0  not null comment '订单实收金额(usdt)',
+    fee_amount   decimal(19, 6) default 0  not null comment '手续费(usdt)',
+    net_amount   decimal(19, 6) default 0  not null comment '扣除手续费后的净额(usdt)',
+    created_at   timestamp                 null,
+    updated_at   timestamp                 null,
+    deleted_at   timestamp                 null,
+    constraint fee_ledger_trade_id_uindex
+        unique (trade_id)
+)
+    comment '平台手续费台账';
+
+create index fee_ledger_merchant_id_created_at_index
+    on fee_ledger (merchant_id, created_at);
+
+-- 20261019 订单商户自定义数据
+
+ALTER TABLE `orders` ADD `customer_email` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '客户邮箱' AFTER `api_key_id`;
+ALTER TABLE `orders` ADD `customer_name` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '客户名称' AFTER `customer_email`;
+ALTER TABLE `orders` ADD `metadata` VARCHAR(2048) NOT NULL DEFAULT '' COMMENT '商户自定义数据json对象' AFTER `customer_name`;
@@ -4,7 +4,9 @@ import (
 	"github.com/assimon/luuu/command"
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/service"
 	"github.com/assimon/luuu/mq"
+	"github.com/assimon/luuu/mq/handle"
 	"github.com/assimon/luuu/task"
 	"github.com/assimon/luuu/telegram"
 	"github.com/assimon/luuu/util/log"
@@ -21,8 +23,10 @@ func Start() {
 	// redis启动
 	dao.RedisInit()
 	// 队列启动
+	handle.EnqueueOrderEvent = service.EnqueueOrderEvent
 	mq.Start()
 	// telegram机器人启动
+	telegram.OrderCallbackResender = service.ResendOrderCallback
 	go telegram.BotStart()
 	// 定时任务
 	go task.Start()
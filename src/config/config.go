@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -17,7 +20,8 @@ var (
 	TgBotToken  string
 	TgProxy     string
 	TgManage    int64
-	UsdtRate    float64
+
+	callbackSuccessRegex *regexp.Regexp
 )
 
 func Init() {
@@ -54,6 +58,24 @@ func Init() {
 	TgBotToken = viper.GetString("tg_bot_token")
 	TgProxy = viper.GetString("tg_proxy")
 	TgManage = viper.GetInt64("tg_manage")
+	// 回调成功正则在启动时编译，配置错误时拒绝启动
+	if err = LoadCallbackSuccessRegex(); err != nil {
+		panic(err)
+	}
+}
+
+// LoadCallbackSuccessRegex regex 模式下编译回调成功正则
+func LoadCallbackSuccessRegex() error {
+	callbackSuccessRegex = nil
+	if GetCallbackSuccessMode() != CallbackSuccessModeRegex {
+		return nil
+	}
+	re, err := regexp.Compile(viper.GetString("callback_success_regex"))
+	if err != nil {
+		return fmt.Errorf("invalid callback_success_regex: %w", err)
+	}
+	callbackSuccessRegex = re
+	return nil
 }
 
 func GetAppVersion() string {
@@ -80,15 +102,102 @@ func GetApiAuthToken() string {
 	return viper.GetString("api_auth_token")
 }
 
-func GetUsdtRate() float64 {
-	forcedUsdtRate := viper.GetFloat64("forced_usdt_rate")
-	if forcedUsdtRate > 0 {
-		return forcedUsdtRate
+const (
+	ApiSignModeMd5  = "md5"  // 仅 md5 签名
+	ApiSignModeHmac = "hmac" // 仅 v2 HMAC-SHA256 签名
+	ApiSignModeBoth = "both" // 请求头带有v2签名时校验v2签名，否则校验md5签名
+)
+
+// GetApiSignMode 接口与回调的签名方式
+func GetApiSignMode() string {
+	mode := strings.ToLower(strings.TrimSpace(viper.GetString("api_sign_mode")))
+	switch mode {
+	case ApiSignModeMd5, ApiSignModeBoth:
+		return mode
+	}
+	// 默认仅接受带时间戳与随机串的v2签名，md5签名需显式开启
+	return ApiSignModeHmac
+}
+
+// GetApiSignWindow v2签名时间戳允许的误差
+func GetApiSignWindow() time.Duration {
+	seconds := viper.GetInt("api_sign_window")
+	if seconds <= 0 {
+		seconds = 300
+	}
+	return time.Second * time.Duration(seconds)
+}
+
+// GetForcedUsdtRate 强制汇率，大于0时不再使用汇率源
+func GetForcedUsdtRate() float64 {
+	return viper.GetFloat64("forced_usdt_rate")
+}
+
+// getStringList 读取逗号分隔的配置项
+func getStringList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(viper.GetString(key), ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// GetUsdtRateProviders 启用的汇率源
+func GetUsdtRateProviders() []string {
+	providers := getStringList("usdt_rate_providers")
+	if len(providers) == 0 {
+		return []string{"coinmarketcap", "coingecko", "cryptocompare", "okx"}
+	}
+	return providers
+}
+
+// GetUsdtRateCurrencies 需要获取汇率的法币，第一个为默认法币
+func GetUsdtRateCurrencies() []string {
+	currencies := getStringList("usdt_rate_currencies")
+	if len(currencies) == 0 {
+		return []string{"cny"}
+	}
+	return currencies
+}
+
+// GetUsdtRateDefaultCurrency 默认法币
+func GetUsdtRateDefaultCurrency() string {
+	return GetUsdtRateCurrencies()[0]
+}
+
+// GetUsdtRateFile 静态汇率文件路径
+func GetUsdtRateFile() string {
+	return viper.GetString("usdt_rate_file")
+}
+
+// GetUsdtRateMaxDeviation 单个汇率源偏离中位数的最大百分比，超过视为异常值
+func GetUsdtRateMaxDeviation() float64 {
+	deviation := viper.GetFloat64("usdt_rate_max_deviation")
+	if deviation <= 0 {
+		return 2
 	}
-	if UsdtRate <= 0 {
-		return 6.4
+	return deviation
+}
+
+// GetUsdtRateMinSources 计算汇率所需的最少有效汇率源数量
+func GetUsdtRateMinSources() int {
+	sources := viper.GetInt("usdt_rate_min_sources")
+	if sources <= 0 {
+		return 1
+	}
+	return sources
+}
+
+// GetUsdtRateMaxStaleness 汇率最大有效期，超过后拒绝创建订单
+func GetUsdtRateMaxStaleness() time.Duration {
+	seconds := viper.GetInt("usdt_rate_max_staleness")
+	if seconds <= 0 {
+		seconds = 600
 	}
-	return UsdtRate
+	return time.Second * time.Duration(seconds)
 }
 
 func GetOrderExpirationTime() int {
@@ -99,6 +208,208 @@ func GetOrderExpirationTime() int {
 	return timer
 }
 
+const (
+	AmountDirectionUp        = "up"        // 向上递增
+	AmountDirectionDown      = "down"      // 向下递减
+	AmountDirectionAlternate = "alternate" // 围绕目标金额上下交替
+	MaxAmountPrecision       = 6           // 金额最大精度
+)
+
+// AmountRule 待支付金额的区分规则，同一钱包同一金额被占用时按规则偏移金额
+type AmountRule struct {
+	Step      float64 // 每次偏移的金额
+	Precision int32   // 金额精度(小数位数)
+	MaxOffset float64 // 最大偏移金额
+	Direction string  // 偏移方向 up / down / alternate
+}
+
+// getChannelConfigKey 链单独配置优先，例如 amount_step_trc20、amount_step_avax_c
+func getChannelConfigKey(key, channel string) string {
+	channelKey := key + "_" + strings.ReplaceAll(channel, "-", "_")
+	if channel != "" && viper.IsSet(channelKey) && viper.GetString(channelKey) != "" {
+		return channelKey
+	}
+	return key
+}
+
+// GetAmountRule 获取链的金额区分规则
+func GetAmountRule(channel string) AmountRule {
+	rule := AmountRule{
+		Step:      viper.GetFloat64(getChannelConfigKey("amount_step", channel)),
+		Precision: viper.GetInt32(getChannelConfigKey("amount_precision", channel)),
+		MaxOffset: viper.GetFloat64(getChannelConfigKey("amount_max_offset", channel)),
+		Direction: viper.GetString(getChannelConfigKey("amount_direction", channel)),
+	}
+	if rule.Precision <= 0 {
+		rule.Precision = 2
+	}
+	if rule.Precision > MaxAmountPrecision {
+		rule.Precision = MaxAmountPrecision
+	}
+	minStep := math.Pow10(-int(rule.Precision))
+	if rule.Step < minStep {
+		rule.Step = minStep
+	}
+	if rule.MaxOffset <= 0 {
+		rule.MaxOffset = 1
+	}
+	switch rule.Direction {
+	case AmountDirectionDown, AmountDirectionAlternate:
+	default:
+		rule.Direction = AmountDirectionUp
+	}
+	return rule
+}
+
+// GetWalletSelectStrategy 钱包分配策略
+func GetWalletSelectStrategy() string {
+	return viper.GetString("wallet_select_strategy")
+}
+
+// GetWalletBalanceInterval 钱包余额检查间隔(秒)
+func GetWalletBalanceInterval() int {
+	interval := viper.GetInt("wallet_balance_interval")
+	if interval <= 0 {
+		return 300
+	}
+	return interval
+}
+
+// GetWalletSweepThreshold 钱包usdt余额归集告警阈值，0为不告警
+func GetWalletSweepThreshold() float64 {
+	return viper.GetFloat64("wallet_sweep_threshold")
+}
+
+// GetWalletReconcileDuration 钱包对账窗口
+func GetWalletReconcileDuration() time.Duration {
+	hours := viper.GetInt("wallet_reconcile_hours")
+	if hours <= 0 {
+		hours = 24
+	}
+	return time.Hour * time.Duration(hours)
+}
+
+// GetWalletReconcileTolerance 钱包对账允许的差额
+func GetWalletReconcileTolerance() float64 {
+	tolerance := viper.GetFloat64("wallet_reconcile_tolerance")
+	if tolerance <= 0 {
+		return 0.01
+	}
+	return tolerance
+}
+
+// GetWalletAlertSilenceDuration 同一钱包同类告警的静默时长
+func GetWalletAlertSilenceDuration() time.Duration {
+	minutes := viper.GetInt("wallet_alert_silence")
+	if minutes <= 0 {
+		minutes = 360
+	}
+	return time.Minute * time.Duration(minutes)
+}
+
+// GetCheckoutSelectChannel 未指定链的订单是否由客户在收银台选择付款网络，关闭时默认收 polygon
+func GetCheckoutSelectChannel() bool {
+	return viper.GetBool("checkout_select_channel")
+}
+
+// GetQuoteTtl 报价有效期
+func GetQuoteTtl() time.Duration {
+	seconds := viper.GetInt("quote_ttl")
+	if seconds <= 0 {
+		seconds = 60
+	}
+	return time.Second * time.Duration(seconds)
+}
+
+// GetCallbackRetrySchedule 异步回调失败后每次重试的间隔，例如 1m,5m,30m,2h,6h,24h
+func GetCallbackRetrySchedule() []time.Duration {
+	var schedule []time.Duration
+	for _, item := range getStringList("callback_retry_schedule") {
+		delay, err := time.ParseDuration(item)
+		if err != nil || delay <= 0 {
+			continue
+		}
+		schedule = append(schedule, delay)
+	}
+	if len(schedule) == 0 {
+		return []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 6 * time.Hour, 24 * time.Hour}
+	}
+	return schedule
+}
+
+// GetNotifyEvents 全局额外开启回调的订单事件，下单未指定时使用
+func GetNotifyEvents() []string {
+	return getStringList("notify_events")
+}
+
+const (
+	CallbackSuccessModeOk       = "ok"        // 响应内容为 ok，忽略大小写、首尾空白与引号
+	CallbackSuccessMode2xx      = "2xx"       // http状态码为2xx
+	CallbackSuccessModeRegex    = "regex"     // 响应内容匹配正则
+	CallbackSuccessModeJsonCode = "json_code" // 响应json的指定字段等于指定值
+
+	CallbackContentTypeJson = "json"
+	CallbackContentTypeForm = "form"
+)
+
+// GetCallbackSuccessMode 回调成功的判断方式
+func GetCallbackSuccessMode() string {
+	mode := strings.ToLower(strings.TrimSpace(viper.GetString("callback_success_mode")))
+	switch mode {
+	case CallbackSuccessMode2xx, CallbackSuccessModeRegex, CallbackSuccessModeJsonCode:
+		return mode
+	}
+	return CallbackSuccessModeOk
+}
+
+// GetCallbackSuccessRegex regex 模式下响应内容需要匹配的正则，启动时已编译
+func GetCallbackSuccessRegex() *regexp.Regexp {
+	return callbackSuccessRegex
+}
+
+// GetCallbackSuccessJsonKey json_code 模式下判断的字段
+func GetCallbackSuccessJsonKey() string {
+	key := strings.TrimSpace(viper.GetString("callback_success_json_key"))
+	if key == "" {
+		return "code"
+	}
+	return key
+}
+
+// GetCallbackSuccessJsonValue json_code 模式下字段需要等于的值
+func GetCallbackSuccessJsonValue() string {
+	if !viper.IsSet("callback_success_json_value") {
+		return "0"
+	}
+	return strings.TrimSpace(viper.GetString("callback_success_json_value"))
+}
+
+// GetCallbackContentType 回调请求的编码方式 json form
+func GetCallbackContentType() string {
+	if strings.ToLower(strings.TrimSpace(viper.GetString("callback_content_type"))) == CallbackContentTypeForm {
+		return CallbackContentTypeForm
+	}
+	return CallbackContentTypeJson
+}
+
+// GetCallbackHeaders 回调请求附加的请求头，格式 Key1:Value1;Key2:Value2
+func GetCallbackHeaders() map[string]string {
+	headers := make(map[string]string)
+	for _, item := range strings.Split(viper.GetString("callback_headers"), ";") {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return headers
+}
+
+// GetCallbackMaxAttempts 异步回调最多发送次数，首次发送加上重试次数
+func GetCallbackMaxAttempts() int {
+	return len(GetCallbackRetrySchedule()) + 1
+}
+
 func GetOrderExpirationTimeDuration() time.Duration {
 	timer := GetOrderExpirationTime()
 	return time.Minute * time.Duration(timer)
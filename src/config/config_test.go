@@ -0,0 +1,76 @@
+package config
+
+import (
+	"math"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// setConfig 临时设置配置项，测试结束后清空
+func setConfig(t *testing.T, values map[string]interface{}) {
+	for key, value := range values {
+		viper.Set(key, value)
+	}
+	t.Cleanup(func() {
+		for key := range values {
+			viper.Set(key, "")
+		}
+	})
+}
+
+func TestGetAmountRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		config  map[string]interface{}
+		want    AmountRule
+	}{
+		{
+			name: "default",
+			want: AmountRule{Step: 0.01, Precision: 2, MaxOffset: 1, Direction: AmountDirectionUp},
+		},
+		{
+			name:   "precision clamped to max",
+			config: map[string]interface{}{"amount_precision": 10},
+			want:   AmountRule{Step: 0.000001, Precision: MaxAmountPrecision, MaxOffset: 1, Direction: AmountDirectionUp},
+		},
+		{
+			name:   "step below precision raised",
+			config: map[string]interface{}{"amount_precision": 4, "amount_step": 0.00001},
+			want:   AmountRule{Step: 0.0001, Precision: 4, MaxOffset: 1, Direction: AmountDirectionUp},
+		},
+		{
+			name:   "custom step and offset",
+			config: map[string]interface{}{"amount_step": 0.05, "amount_max_offset": 0.5, "amount_direction": AmountDirectionAlternate},
+			want:   AmountRule{Step: 0.05, Precision: 2, MaxOffset: 0.5, Direction: AmountDirectionAlternate},
+		},
+		{
+			name:   "unknown direction",
+			config: map[string]interface{}{"amount_direction": "sideways", "amount_max_offset": -1},
+			want:   AmountRule{Step: 0.01, Precision: 2, MaxOffset: 1, Direction: AmountDirectionUp},
+		},
+		{
+			name:    "channel override",
+			channel: "avax-c",
+			config:  map[string]interface{}{"amount_step": 0.05, "amount_step_avax_c": 0.1, "amount_direction_avax_c": AmountDirectionDown},
+			want:    AmountRule{Step: 0.1, Precision: 2, MaxOffset: 1, Direction: AmountDirectionDown},
+		},
+		{
+			name:    "empty channel value falls back",
+			channel: "trc20",
+			config:  map[string]interface{}{"amount_step": 0.05, "amount_step_trc20": ""},
+			want:    AmountRule{Step: 0.05, Precision: 2, MaxOffset: 1, Direction: AmountDirectionUp},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, tt.config)
+			got := GetAmountRule(tt.channel)
+			if got.Precision != tt.want.Precision || got.Direction != tt.want.Direction ||
+				math.Abs(got.Step-tt.want.Step) > 1e-12 || math.Abs(got.MaxOffset-tt.want.MaxOffset) > 1e-12 {
+				t.Errorf("GetAmountRule(%q) = %+v, want %+v", tt.channel, got, tt.want)
+			}
+		})
+	}
+}
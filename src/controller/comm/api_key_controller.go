@@ -0,0 +1,65 @@
+package comm
+
+import (
+	"github.com/assimon/luuu/middleware"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// ApiKeyList api密钥列表
+func (c *BaseCommController) ApiKeyList(ctx echo.Context) (err error) {
+	req := new(request.ApiKeyListRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if !middleware.IsPlatformApiKey(ctx) {
+		req.MerchantId = middleware.GetMerchantId(ctx)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetApiKeyList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// ApiKeySave 新增或修改api密钥
+func (c *BaseCommController) ApiKeySave(ctx echo.Context) (err error) {
+	req := new(request.ApiKeySaveRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if !middleware.IsPlatformApiKey(ctx) {
+		req.MerchantId = middleware.GetMerchantId(ctx)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.SaveApiKey(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// ApiKeyDelete 删除api密钥
+func (c *BaseCommController) ApiKeyDelete(ctx echo.Context) (err error) {
+	req := new(request.ApiKeyDeleteRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if !middleware.IsPlatformApiKey(ctx) {
+		req.MerchantId = middleware.GetMerchantId(ctx)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err = service.DeleteApiKey(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, nil)
+}
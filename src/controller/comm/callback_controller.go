@@ -0,0 +1,43 @@
+package comm
+
+import (
+	"github.com/assimon/luuu/middleware"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// CallbackLog 回调投递记录
+func (c *BaseCommController) CallbackLog(ctx echo.Context) (err error) {
+	req := new(request.CallbackLogRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetCallbackLogList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// CallbackResend 手动重新回调
+func (c *BaseCommController) CallbackResend(ctx echo.Context) (err error) {
+	req := new(request.CallbackResendRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.ResendOrderCallbackByApi(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
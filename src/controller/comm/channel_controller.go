@@ -0,0 +1,26 @@
+package comm
+
+import (
+	"github.com/assimon/luuu/middleware"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// ChannelList 支持的链列表
+func (c *BaseCommController) ChannelList(ctx echo.Context) (err error) {
+	req := new(request.ChannelListRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.GetChannelList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
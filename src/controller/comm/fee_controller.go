@@ -0,0 +1,94 @@
+package comm
+
+import (
+	"github.com/assimon/luuu/middleware"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// FeeRuleList 手续费规则列表
+func (c *BaseCommController) FeeRuleList(ctx echo.Context) (err error) {
+	req := new(request.FeeRuleListRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetFeeRuleList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// FeeRuleSave 新增或修改手续费规则
+func (c *BaseCommController) FeeRuleSave(ctx echo.Context) (err error) {
+	req := new(request.FeeRuleSaveRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.SaveFeeRule(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// FeeRuleDelete 删除手续费规则
+func (c *BaseCommController) FeeRuleDelete(ctx echo.Context) (err error) {
+	req := new(request.FeeRuleDeleteRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err = service.DeleteFeeRule(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, nil)
+}
+
+// FeeLedger 手续费台账
+func (c *BaseCommController) FeeLedger(ctx echo.Context) (err error) {
+	req := new(request.FeeLedgerRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if !middleware.IsPlatformApiKey(ctx) {
+		req.MerchantId = middleware.GetMerchantId(ctx)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetFeeLedgerList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// FeeStatement 手续费对账单
+func (c *BaseCommController) FeeStatement(ctx echo.Context) (err error) {
+	req := new(request.FeeStatementRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if !middleware.IsPlatformApiKey(ctx) {
+		req.MerchantId = middleware.GetMerchantId(ctx)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.GetFeeStatement(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
@@ -0,0 +1,40 @@
+package comm
+
+import (
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// MerchantList 商户列表
+func (c *BaseCommController) MerchantList(ctx echo.Context) (err error) {
+	req := new(request.MerchantListRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetMerchantList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// MerchantSave 新增或修改商户
+func (c *BaseCommController) MerchantSave(ctx echo.Context) (err error) {
+	req := new(request.MerchantSaveRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.SaveMerchant(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
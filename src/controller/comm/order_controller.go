@@ -1,6 +1,7 @@
 package comm
 
 import (
+	"github.com/assimon/luuu/middleware"
 	"github.com/assimon/luuu/model/request"
 	"github.com/assimon/luuu/model/service"
 	"github.com/assimon/luuu/util/constant"
@@ -13,6 +14,8 @@ func (c *BaseCommController) CreateTransaction(ctx echo.Context) (err error) {
 	if err = ctx.Bind(req); err != nil {
 		return c.FailJson(ctx, constant.ParamsMarshalErr)
 	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	req.ApiKeyId = middleware.GetApiKeyId(ctx)
 	if err = c.ValidateStruct(ctx, req); err != nil {
 		return c.FailJson(ctx, err)
 	}
@@ -22,3 +25,71 @@ func (c *BaseCommController) CreateTransaction(ctx echo.Context) (err error) {
 	}
 	return c.SucJson(ctx, resp)
 }
+
+// Quote 报价
+func (c *BaseCommController) Quote(ctx echo.Context) (err error) {
+	req := new(request.QuoteRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.CreateQuote(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// SwitchChannel 切换订单付款网络
+func (c *BaseCommController) SwitchChannel(ctx echo.Context) (err error) {
+	req := new(request.SwitchChannelRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.SwitchOrderChannelByApi(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// CancelOrder 取消订单
+func (c *BaseCommController) CancelOrder(ctx echo.Context) (err error) {
+	req := new(request.CancelOrderRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.CancelOrder(req.MerchantId, req.TradeId)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// QueryOrder 查询订单
+func (c *BaseCommController) QueryOrder(ctx echo.Context) (err error) {
+	req := new(request.OrderQueryRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.QueryOrder(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
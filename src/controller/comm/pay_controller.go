@@ -3,8 +3,11 @@ package comm
 import (
 	"fmt"
 	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
 	"github.com/assimon/luuu/model/response"
 	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
 	"github.com/labstack/echo/v4"
 	"html/template"
 	"net/http"
@@ -37,3 +40,33 @@ func (c *BaseCommController) CheckStatus(ctx echo.Context) (err error) {
 	}
 	return c.SucJson(ctx, resp)
 }
+
+// SelectChannel 收银台选择付款网络
+func (c *BaseCommController) SelectChannel(ctx echo.Context) (err error) {
+	req := new(request.SelectChannelRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err = service.SelectOrderChannel(ctx.Param("trade_id"), req.Channel); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, nil)
+}
+
+// CheckoutSwitchChannel 收银台切换付款网络
+func (c *BaseCommController) CheckoutSwitchChannel(ctx echo.Context) (err error) {
+	req := new(request.SelectChannelRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if _, err = service.SwitchOrderChannel(ctx.Param("trade_id"), req.Channel, mdb.ChannelSwitchSourceCheckout); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, nil)
+}
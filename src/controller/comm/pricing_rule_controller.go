@@ -0,0 +1,59 @@
+package comm
+
+import (
+	"github.com/assimon/luuu/middleware"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// PricingRuleList 定价规则列表
+func (c *BaseCommController) PricingRuleList(ctx echo.Context) (err error) {
+	req := new(request.PricingRuleListRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetPricingRuleList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// PricingRuleSave 新增或修改定价规则
+func (c *BaseCommController) PricingRuleSave(ctx echo.Context) (err error) {
+	req := new(request.PricingRuleSaveRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.SavePricingRule(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// PricingRuleDelete 删除定价规则
+func (c *BaseCommController) PricingRuleDelete(ctx echo.Context) (err error) {
+	req := new(request.PricingRuleDeleteRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	if err = service.DeletePricingRule(req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, nil)
+}
@@ -0,0 +1,42 @@
+package comm
+
+import (
+	"strings"
+
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// RateCurrent 当前汇率
+func (c *BaseCommController) RateCurrent(ctx echo.Context) (err error) {
+	req := new(request.RateCurrentRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.GetCurrentUsdtRates(strings.ToLower(req.Currency))
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// RateHistory 汇率历史
+func (c *BaseCommController) RateHistory(ctx echo.Context) (err error) {
+	req := new(request.RateHistoryRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetUsdtRateHistory(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
@@ -0,0 +1,86 @@
+package comm
+
+import (
+	"github.com/assimon/luuu/middleware"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/labstack/echo/v4"
+)
+
+// WalletBalance 钱包最新余额
+func (c *BaseCommController) WalletBalance(ctx echo.Context) (err error) {
+	resp, err := service.GetLatestWalletBalances(middleware.GetMerchantId(ctx))
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// WalletBalanceHistory 钱包余额历史
+func (c *BaseCommController) WalletBalanceHistory(ctx echo.Context) (err error) {
+	req := new(request.WalletBalanceHistoryRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetWalletBalanceHistory(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// WalletList 钱包列表
+func (c *BaseCommController) WalletList(ctx echo.Context) (err error) {
+	req := new(request.WalletListRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, pagination, err := service.GetWalletAddressList(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJsonPage(ctx, resp, pagination)
+}
+
+// WalletUpdate 修改钱包信息
+func (c *BaseCommController) WalletUpdate(ctx echo.Context) (err error) {
+	req := new(request.WalletUpdateRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.UpdateWalletAddressInfo(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
+
+// WalletAdd 添加钱包
+func (c *BaseCommController) WalletAdd(ctx echo.Context) (err error) {
+	req := new(request.WalletAddRequest)
+	if err = ctx.Bind(req); err != nil {
+		return c.FailJson(ctx, constant.ParamsMarshalErr)
+	}
+	req.MerchantId = middleware.GetMerchantId(ctx)
+	if err = c.ValidateStruct(ctx, req); err != nil {
+		return c.FailJson(ctx, err)
+	}
+	resp, err := service.AddWalletAddress(req)
+	if err != nil {
+		return c.FailJson(ctx, err)
+	}
+	return c.SucJson(ctx, resp)
+}
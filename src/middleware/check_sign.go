@@ -2,14 +2,23 @@ package middleware
 
 import (
 	"bytes"
+	"io/ioutil"
+	"strconv"
+	"time"
+
 	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/util/constant"
 	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/log"
 	"github.com/assimon/luuu/util/sign"
 	"github.com/labstack/echo/v4"
-	"io/ioutil"
 )
 
+// ContextApiKey 请求上下文中保存密钥的键
+const ContextApiKey = "api_key"
+
 func CheckApiSign() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
@@ -18,20 +27,168 @@ func CheckApiSign() echo.MiddlewareFunc {
 				return constant.SignatureErr
 			}
 			m := make(map[string]interface{})
-			err = json.Cjson.Unmarshal(params, &m)
-			signature, ok := m["signature"]
-			if !ok {
+			if err = json.CjsonNumber.Unmarshal(params, &m); err != nil {
 				return constant.SignatureErr
 			}
-			checkSignature, err := sign.Get(m, config.GetApiAuthToken())
+			apiKey, err := resolveApiKey(ctx, m)
 			if err != nil {
-				return constant.SignatureErr
+				return err
 			}
-			if checkSignature != signature {
-				return constant.SignatureErr
+			mode := config.GetApiSignMode()
+			if ctx.Request().Header.Get(sign.HeaderSignature) != "" && mode != config.ApiSignModeMd5 {
+				err = checkSignV2(ctx, m, apiKey)
+			} else if mode != config.ApiSignModeHmac {
+				err = checkSignMd5(m, apiKey.Secret)
+			} else {
+				err = constant.SignatureErr
+			}
+			if err != nil {
+				return err
 			}
+			ctx.Set(ContextApiKey, apiKey)
 			ctx.Request().Body = ioutil.NopCloser(bytes.NewBuffer(params))
 			return next(ctx)
 		}
 	}
 }
+
+// RequireScope 校验请求密钥是否拥有接口权限，需在 CheckApiSign 之后使用
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			apiKey := GetApiKey(ctx)
+			if apiKey == nil || !apiKey.HasScope(scope) {
+				return constant.ApiKeyScopeErr
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// GetApiKey 获取本次请求使用的密钥
+func GetApiKey(ctx echo.Context) *mdb.ApiKey {
+	apiKey, _ := ctx.Get(ContextApiKey).(*mdb.ApiKey)
+	return apiKey
+}
+
+// RequirePlatform 校验请求密钥是否为平台管理密钥，即默认商户拥有 admin 权限的密钥或 api_auth_token
+func RequirePlatform() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if !IsPlatformApiKey(ctx) {
+				return constant.ApiKeyScopeErr
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// IsPlatformApiKey 本次请求是否使用平台管理密钥，平台管理密钥可管理所有商户
+func IsPlatformApiKey(ctx echo.Context) bool {
+	apiKey := GetApiKey(ctx)
+	return apiKey != nil && apiKey.MerchantId == mdb.DefaultMerchantId && apiKey.HasScope(mdb.ApiKeyScopeAdmin)
+}
+
+// GetMerchantId 获取本次请求密钥所属的商户id
+func GetMerchantId(ctx echo.Context) uint64 {
+	if apiKey := GetApiKey(ctx); apiKey != nil {
+		return apiKey.MerchantId
+	}
+	return mdb.DefaultMerchantId
+}
+
+// GetApiKeyId 获取本次请求使用的密钥id，使用 api_auth_token 时为空
+func GetApiKeyId(ctx echo.Context) string {
+	if apiKey := GetApiKey(ctx); apiKey != nil {
+		return apiKey.KeyId
+	}
+	return ""
+}
+
+// resolveApiKey 通过请求头或请求参数中的密钥id获取密钥
+// 未指定密钥id时使用 api_auth_token，属于默认商户并拥有全部权限，兼容旧版接入
+func resolveApiKey(ctx echo.Context, m map[string]interface{}) (*mdb.ApiKey, error) {
+	keyId := ctx.Request().Header.Get(sign.HeaderKeyId)
+	if keyId == "" {
+		keyId, _ = m["key_id"].(string)
+	}
+	if keyId == "" {
+		return &mdb.ApiKey{
+			Secret: config.GetApiAuthToken(),
+			Scopes: mdb.ApiKeyScopeAdmin,
+			Status: mdb.ApiKeyStatusEnable,
+		}, nil
+	}
+	apiKey, err := data.GetApiKeyByKeyId(keyId)
+	if err != nil {
+		log.Sugar.Error(err)
+		return nil, constant.SignatureErr
+	}
+	if apiKey.ID <= 0 || !apiKey.IsUsable() {
+		return nil, constant.ApiKeyNotExists
+	}
+	if apiKey.MerchantId != mdb.DefaultMerchantId {
+		merchant, err := data.GetMerchantById(apiKey.MerchantId)
+		if err != nil {
+			log.Sugar.Error(err)
+			return nil, constant.SignatureErr
+		}
+		if merchant.ID <= 0 || merchant.Status != mdb.MerchantStatusEnable {
+			return nil, constant.MerchantNotExists
+		}
+	}
+	return apiKey, nil
+}
+
+// checkSignMd5 校验请求参数中的md5签名
+func checkSignMd5(m map[string]interface{}, secret string) error {
+	signature, ok := m["signature"].(string)
+	if !ok {
+		return constant.SignatureErr
+	}
+	checkSignature, err := sign.Get(m, secret)
+	if err != nil || !sign.Equal(signature, checkSignature) {
+		return constant.SignatureErr
+	}
+	return nil
+}
+
+// checkSignV2 校验请求头中的v2签名，时间戳需在允许误差内，随机串在有效期内只能使用一次
+func checkSignV2(ctx echo.Context, m map[string]interface{}, apiKey *mdb.ApiKey) error {
+	header := ctx.Request().Header
+	timestamp := header.Get(sign.HeaderTimestamp)
+	nonce := header.Get(sign.HeaderNonce)
+	signature := header.Get(sign.HeaderSignature)
+	if len(nonce) < sign.NonceMinLength || len(nonce) > sign.NonceMaxLength {
+		return constant.SignatureErr
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return constant.SignatureErr
+	}
+	window := config.GetApiSignWindow()
+	if diff := time.Since(time.Unix(unix, 0)); diff > window || diff < -window {
+		return constant.SignatureExpiredErr
+	}
+	params, err := sign.Params(m)
+	if err != nil {
+		return constant.SignatureErr
+	}
+	req := ctx.Request()
+	if !sign.Equal(signature, sign.HmacSha256(req.Method, req.URL.Path, timestamp, nonce, params, apiKey.Secret)) {
+		return constant.SignatureErr
+	}
+	// 签名校验通过后再记录随机串，避免伪造请求占用随机串，不同密钥的随机串互不影响
+	if apiKey.KeyId != "" {
+		nonce = apiKey.KeyId + ":" + nonce
+	}
+	ok, err := data.AcquireSignNonce(nonce, 2*window)
+	if err != nil {
+		log.Sugar.Error(err)
+		return constant.SignatureErr
+	}
+	if !ok {
+		return constant.SignatureNonceErr
+	}
+	return nil
+}
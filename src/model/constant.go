@@ -8,4 +8,49 @@ const (
 	ChainNamePolygonPOS = "polygon"
 	ChainNameAptos      = "aptos"
 	ChainNameArbitrum   = "arb"
+
+	AssetUsdt = "USDT"
 )
+
+// ChainInfo 链信息
+type ChainInfo struct {
+	Code           string   // 链编码，即订单的 channel
+	DisplayName    string   // 展示名称
+	Assets         []string // 支持收款的币种
+	ConfirmSeconds int      // 预计到账确认时间(秒)，含扫块间隔
+}
+
+// Chains 支持的链，按展示顺序排列
+var Chains = []ChainInfo{
+	{Code: ChainNameTRC20, DisplayName: "TRON - TRC20", Assets: []string{AssetUsdt}, ConfirmSeconds: 60},
+	{Code: ChainNamePolygonPOS, DisplayName: "Polygon PoS Chain (POL)", Assets: []string{AssetUsdt}, ConfirmSeconds: 60},
+	{Code: ChainNameBSC, DisplayName: "BNB Smart Chain - BEP20", Assets: []string{AssetUsdt}, ConfirmSeconds: 30},
+	{Code: ChainNameAVAXC, DisplayName: "Avalanche (C-Chain)", Assets: []string{AssetUsdt}, ConfirmSeconds: 30},
+	{Code: ChainNameETH, DisplayName: "Ethereum - ERC20", Assets: []string{AssetUsdt}, ConfirmSeconds: 180},
+	{Code: ChainNameAptos, DisplayName: "Aptos", Assets: []string{AssetUsdt}, ConfirmSeconds: 30},
+	{Code: ChainNameArbitrum, DisplayName: "Arbitrum One", Assets: []string{AssetUsdt}, ConfirmSeconds: 30},
+}
+
+// GetChainInfo 通过链编码获取链信息
+func GetChainInfo(chainName string) (ChainInfo, bool) {
+	for _, chain := range Chains {
+		if chain.Code == chainName {
+			return chain, true
+		}
+	}
+	return ChainInfo{}, false
+}
+
+// IsChainSupported 是否为支持的链
+func IsChainSupported(chainName string) bool {
+	_, ok := GetChainInfo(chainName)
+	return ok
+}
+
+// GetChainDisplayName 获取链的展示名称，未知链原样返回
+func GetChainDisplayName(chainName string) string {
+	if chain, ok := GetChainInfo(chainName); ok {
+		return chain.DisplayName
+	}
+	return chainName
+}
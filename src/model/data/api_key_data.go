@@ -0,0 +1,43 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+// GetApiKeyByKeyId 通过密钥id获取接口密钥
+func GetApiKeyByKeyId(keyId string) (*mdb.ApiKey, error) {
+	apiKey := new(mdb.ApiKey)
+	err := dao.Mdb.Model(apiKey).Limit(1).Find(apiKey, "key_id = ?", keyId).Error
+	return apiKey, err
+}
+
+// GetApiKeyById 通过id获取接口密钥
+func GetApiKeyById(id uint64) (*mdb.ApiKey, error) {
+	apiKey := new(mdb.ApiKey)
+	err := dao.Mdb.Model(apiKey).Limit(1).Find(apiKey, id).Error
+	return apiKey, err
+}
+
+// GetApiKeyList 分页获取商户的接口密钥
+func GetApiKeyList(merchantId uint64, page, pageSize int) ([]mdb.ApiKey, int64, error) {
+	var apiKeys []mdb.ApiKey
+	var total int64
+	query := dao.Mdb.Model(&mdb.ApiKey{}).Where("merchant_id = ?", merchantId)
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&apiKeys).Error
+	return apiKeys, total, err
+}
+
+// SaveApiKey 新增或修改接口密钥
+func SaveApiKey(apiKey *mdb.ApiKey) error {
+	return dao.Mdb.Save(apiKey).Error
+}
+
+// DeleteApiKeyById 通过id删除接口密钥
+func DeleteApiKeyById(id uint64) error {
+	return dao.Mdb.Where("id = ?", id).Delete(&mdb.ApiKey{}).Error
+}
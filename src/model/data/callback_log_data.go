@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+var (
+	CacheOrderEventKey = "order_event:%s:%s" // 交易号 : 事件 -> 首次触发时间
+)
+
+// AcquireOrderEvent 获取订单事件的回调权，有效期内同一订单的同一事件只回调一次
+func AcquireOrderEvent(tradeId, event string, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(CacheOrderEventKey, tradeId, event)
+	return dao.Rdb.SetNX(ctx, cacheKey, time.Now().Unix(), ttl).Result()
+}
+
+// CreateCallbackLog 保存回调投递记录
+func CreateCallbackLog(callbackLog *mdb.CallbackLog) error {
+	return dao.Mdb.Create(callbackLog).Error
+}
+
+// GetCallbackLogList 按条件分页获取商户的回调投递记录
+func GetCallbackLogList(merchantId uint64, tradeId, orderId string, status int, startTime, endTime string, page, pageSize int) ([]mdb.CallbackLog, int64, error) {
+	var callbackLogs []mdb.CallbackLog
+	var total int64
+	query := dao.Mdb.Model(&mdb.CallbackLog{}).Where("merchant_id = ?", merchantId)
+	if tradeId != "" {
+		query = query.Where("trade_id = ?", tradeId)
+	}
+	if orderId != "" {
+		query = query.Where("order_id = ?", orderId)
+	}
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	if startTime != "" {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if endTime != "" {
+		query = query.Where("created_at <= ?", endTime)
+	}
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&callbackLogs).Error
+	return callbackLogs, total, err
+}
+
+// GetLatestCallbackLogs 获取订单最近的回调投递记录
+func GetLatestCallbackLogs(tradeId string, limit int) ([]mdb.CallbackLog, error) {
+	var callbackLogs []mdb.CallbackLog
+	err := dao.Mdb.Model(&mdb.CallbackLog{}).Where("trade_id = ?", tradeId).Order("id desc").Limit(limit).Find(&callbackLogs).Error
+	return callbackLogs, err
+}
+
+// CreateCallbackResendLog 保存手动重新回调记录
+func CreateCallbackResendLog(resendLog *mdb.CallbackResendLog) error {
+	return dao.Mdb.Create(resendLog).Error
+}
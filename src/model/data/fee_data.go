@@ -0,0 +1,115 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"gorm.io/gorm"
+)
+
+// FeeStatementRow 手续费对账单按链汇总的结果
+type FeeStatementRow struct {
+	Channel     string  `gorm:"column:channel"`
+	OrderCount  int64   `gorm:"column:order_count"`
+	GrossAmount float64 `gorm:"column:gross_amount"`
+	FeeAmount   float64 `gorm:"column:fee_amount"`
+	NetAmount   float64 `gorm:"column:net_amount"`
+}
+
+// GetEnabledFeeRules 获取商户可匹配链的启用手续费规则
+func GetEnabledFeeRules(merchantId uint64, channel string) ([]mdb.FeeRule, error) {
+	var rules []mdb.FeeRule
+	err := dao.Mdb.Model(&mdb.FeeRule{}).
+		Where("merchant_id = ?", merchantId).
+		Where("status = ?", mdb.FeeRuleStatusEnable).
+		Where("channel in (?, '')", channel).
+		Order("id").
+		Find(&rules).Error
+	return rules, err
+}
+
+// GetFeeRuleById 通过id获取手续费规则
+func GetFeeRuleById(id uint64) (*mdb.FeeRule, error) {
+	rule := new(mdb.FeeRule)
+	err := dao.Mdb.Model(rule).Limit(1).Find(rule, id).Error
+	return rule, err
+}
+
+// GetFeeRuleList 按条件分页获取商户的手续费规则
+func GetFeeRuleList(merchantId uint64, channel string, page, pageSize int) ([]mdb.FeeRule, int64, error) {
+	var rules []mdb.FeeRule
+	var total int64
+	query := dao.Mdb.Model(&mdb.FeeRule{}).Where("merchant_id = ?", merchantId)
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&rules).Error
+	return rules, total, err
+}
+
+// SaveFeeRule 新增或修改手续费规则
+func SaveFeeRule(rule *mdb.FeeRule) error {
+	return dao.Mdb.Save(rule).Error
+}
+
+// DeleteFeeRuleById 通过id删除手续费规则
+func DeleteFeeRuleById(id uint64) error {
+	return dao.Mdb.Where("id = ?", id).Delete(&mdb.FeeRule{}).Error
+}
+
+// CreateFeeLedgerWithTransaction 事务记录手续费台账
+func CreateFeeLedgerWithTransaction(tx *gorm.DB, ledger *mdb.FeeLedger) error {
+	return tx.Create(ledger).Error
+}
+
+// GetFeeLedgerByTradeId 通过交易号获取手续费台账
+func GetFeeLedgerByTradeId(tradeId string) (*mdb.FeeLedger, error) {
+	ledger := new(mdb.FeeLedger)
+	err := dao.Mdb.Model(ledger).Limit(1).Find(ledger, "trade_id = ?", tradeId).Error
+	return ledger, err
+}
+
+// feeLedgerScope 按商户、链与计提时间筛选手续费台账
+func feeLedgerScope(merchantId uint64, channel, startTime, endTime string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("merchant_id = ?", merchantId)
+		if channel != "" {
+			db = db.Where("channel = ?", channel)
+		}
+		if startTime != "" {
+			db = db.Where("created_at >= ?", startTime)
+		}
+		if endTime != "" {
+			db = db.Where("created_at <= ?", endTime)
+		}
+		return db
+	}
+}
+
+// GetFeeLedgerList 按条件分页获取商户的手续费台账
+func GetFeeLedgerList(merchantId uint64, channel, startTime, endTime string, page, pageSize int) ([]mdb.FeeLedger, int64, error) {
+	var ledgers []mdb.FeeLedger
+	var total int64
+	query := dao.Mdb.Model(&mdb.FeeLedger{}).Scopes(feeLedgerScope(merchantId, channel, startTime, endTime))
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&ledgers).Error
+	return ledgers, total, err
+}
+
+// GetFeeStatement 按链汇总商户在时间段内的订单数、实收金额、手续费与净额
+func GetFeeStatement(merchantId uint64, channel, startTime, endTime string) ([]FeeStatementRow, error) {
+	var rows []FeeStatementRow
+	err := dao.Mdb.Model(&mdb.FeeLedger{}).
+		Select("channel, count(*) as order_count, sum(gross_amount) as gross_amount, sum(fee_amount) as fee_amount, sum(net_amount) as net_amount").
+		Scopes(feeLedgerScope(merchantId, channel, startTime, endTime)).
+		Group("channel").
+		Order("channel").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,31 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+// GetMerchantById 通过id获取商户
+func GetMerchantById(id uint64) (*mdb.Merchant, error) {
+	merchant := new(mdb.Merchant)
+	err := dao.Mdb.Model(merchant).Limit(1).Find(merchant, id).Error
+	return merchant, err
+}
+
+// GetMerchantList 分页获取商户
+func GetMerchantList(page, pageSize int) ([]mdb.Merchant, int64, error) {
+	var merchants []mdb.Merchant
+	var total int64
+	query := dao.Mdb.Model(&mdb.Merchant{})
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&merchants).Error
+	return merchants, total, err
+}
+
+// SaveMerchant 新增或修改商户
+func SaveMerchant(merchant *mdb.Merchant) error {
+	return dao.Mdb.Save(merchant).Error
+}
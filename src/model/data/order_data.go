@@ -1,25 +1,19 @@
 package data
 
 import (
-	"context"
-	"fmt"
-	"time"
+	"errors"
 
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/model/request"
-	"github.com/go-redis/redis/v8"
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
-var (
-	CacheWalletAddressWithAmountToTradeIdKey = "wallet:%s_%v" // 钱包（带有链前缀）_待支付金额 : 交易号
-)
-
-// GetOrderInfoByOrderId 通过客户订单号查询订单
-func GetOrderInfoByOrderId(orderId string) (*mdb.Orders, error) {
+// GetMerchantOrderByOrderId 通过商户的客户订单号查询订单
+func GetMerchantOrderByOrderId(merchantId uint64, orderId string) (*mdb.Orders, error) {
 	order := new(mdb.Orders)
-	err := dao.Mdb.Model(order).Limit(1).Find(order, "order_id = ?", orderId).Error
+	err := dao.Mdb.Model(order).Limit(1).Find(order, "merchant_id = ? AND order_id = ?", merchantId, orderId).Error
 	return order, err
 }
 
@@ -36,6 +30,12 @@ func CreateOrderWithTransaction(tx *gorm.DB, order *mdb.Orders) error {
 	return err
 }
 
+// IsDuplicateKeyErr 是否为唯一索引冲突
+func IsDuplicateKeyErr(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
 // GetOrderByBlockIdWithTransaction 通过区块获取订单
 func GetOrderByBlockIdWithTransaction(tx *gorm.DB, blockId string) (*mdb.Orders, error) {
 	order := new(mdb.Orders)
@@ -43,27 +43,70 @@ func GetOrderByBlockIdWithTransaction(tx *gorm.DB, blockId string) (*mdb.Orders,
 	return order, err
 }
 
-// OrderSuccessWithTransaction 事务支付成功
-func OrderSuccessWithTransaction(tx *gorm.DB, req *request.OrderProcessingRequest) error {
-	err := tx.Model(&mdb.Orders{}).Where("trade_id = ?", req.TradeId).Updates(map[string]interface{}{
-		"block_transaction_id": req.BlockTransactionId,
-		"status":               mdb.StatusPaySuccess,
-		"callback_confirm":     mdb.CallBackConfirmNo,
-	}).Error
-	return err
+// GetOrderByTradeIdWithTransaction 事务通过交易号获取订单
+func GetOrderByTradeIdWithTransaction(tx *gorm.DB, tradeId string) (*mdb.Orders, error) {
+	order := new(mdb.Orders)
+	err := tx.Model(order).Limit(1).Find(order, "trade_id = ?", tradeId).Error
+	return order, err
+}
+
+// OrderSuccessWithTransaction 事务将待支付订单标记为支付成功，返回是否标记成功
+// 已过期或已取消的订单已回调最终事件，不再改为支付成功
+func OrderSuccessWithTransaction(tx *gorm.DB, req *request.OrderProcessingRequest) (bool, error) {
+	result := tx.Model(&mdb.Orders{}).
+		Where("trade_id = ?", req.TradeId).
+		Where("status = ?", mdb.StatusWaitPay).
+		Updates(map[string]interface{}{
+			"block_transaction_id": req.BlockTransactionId,
+			"status":               mdb.StatusPaySuccess,
+			"callback_confirm":     mdb.CallBackConfirmNo,
+		})
+	return result.RowsAffected > 0, result.Error
 }
 
 // GetPendingCallbackOrders 查询出等待回调的订单
-func GetPendingCallbackOrders() ([]mdb.Orders, error) {
+func GetPendingCallbackOrders(maxAttempts int) ([]mdb.Orders, error) {
 	var orders []mdb.Orders
 	err := dao.Mdb.Model(orders).
-		Where("callback_num < ?", 5).
+		Where("callback_num < ?", maxAttempts).
 		Where("callback_confirm = ?", mdb.CallBackConfirmNo).
-		Where("status = ?", mdb.StatusPaySuccess).
+		Scopes(callbackEventScope).
 		Find(&orders).Error
 	return orders, err
 }
 
+// callbackEventScope 需要回调最终事件的订单：已支付，或已过期、已取消且开启了对应事件
+func callbackEventScope(db *gorm.DB) *gorm.DB {
+	return db.Where("(status = ? OR (status = ? AND FIND_IN_SET(?, notify_events)) OR (status = ? AND FIND_IN_SET(?, notify_events)))",
+		mdb.StatusPaySuccess, mdb.StatusExpired, mdb.OrderEventExpired, mdb.StatusCancelled, mdb.OrderEventCancelled)
+}
+
+// GetResendCallbackOrders 查询商户需要重新回调的订单
+func GetResendCallbackOrders(merchantId uint64, callbackConfirms []int, startTime, endTime string, limit int) ([]mdb.Orders, error) {
+	var orders []mdb.Orders
+	query := dao.Mdb.Model(orders).
+		Where("merchant_id = ?", merchantId).
+		Scopes(callbackEventScope).
+		Where("callback_confirm in ?", callbackConfirms)
+	if startTime != "" {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if endTime != "" {
+		query = query.Where("created_at <= ?", endTime)
+	}
+	err := query.Order("id").Limit(limit).Find(&orders).Error
+	return orders, err
+}
+
+// ResetOrderCallbackById 重置订单回调次数与状态，以便重新回调
+func ResetOrderCallbackById(id uint64) error {
+	err := dao.Mdb.Model(&mdb.Orders{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"callback_num":     0,
+		"callback_confirm": mdb.CallBackConfirmNo,
+	}).Error
+	return err
+}
+
 // SaveCallBackOrdersResp 保存订单回调结果
 func SaveCallBackOrdersResp(order *mdb.Orders) error {
 	err := dao.Mdb.Model(order).Where("id = ?", order.ID).Updates(map[string]interface{}{
@@ -73,77 +116,43 @@ func SaveCallBackOrdersResp(order *mdb.Orders) error {
 	return err
 }
 
-// UpdateOrderIsExpirationById 通过id设置订单过期
-func UpdateOrderIsExpirationById(id uint64) error {
-	err := dao.Mdb.Model(mdb.Orders{}).Where("id = ?", id).Update("status", mdb.StatusExpired).Error
-	return err
+// UpdateOrderChannelById 待支付订单的收款钱包仍为 oldToken 时设置新的收款钱包，返回是否设置成功
+func UpdateOrderChannelById(id uint64, oldToken string, values map[string]interface{}) (bool, error) {
+	result := dao.Mdb.Model(&mdb.Orders{}).
+		Where("id = ?", id).
+		Where("token = ?", oldToken).
+		Where("status = ?", mdb.StatusWaitPay).
+		Updates(values)
+	return result.RowsAffected > 0, result.Error
 }
 
-// GetTradeIdByWalletAddressAndAmount 通过钱包地址，支付金额获取交易号
-func GetTradeIdByWalletAddressAndAmount(tokenWithChainPrefix string, amount float64) (string, error) {
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, tokenWithChainPrefix, amount)
-	result, err := dao.Rdb.Get(ctx, cacheKey).Result()
-	if err == redis.Nil {
-		return "", nil
-	}
-	if err != nil {
-		return "", err
-	}
-	return result, nil
+// CreateOrderChannelHistory 记录订单付款网络切换
+func CreateOrderChannelHistory(history *mdb.OrderChannelHistory) error {
+	return dao.Mdb.Create(history).Error
 }
 
-// LockTransaction 锁定交易
-func LockTransaction(tokenWithChainPrefix, tradeId string, amount float64, expirationTime time.Duration) error {
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, tokenWithChainPrefix, amount)
-	err := dao.Rdb.Set(ctx, cacheKey, tradeId, expirationTime).Err()
-	return err
+// GetOrderChannelHistory 获取订单付款网络切换记录
+func GetOrderChannelHistory(tradeId string) ([]mdb.OrderChannelHistory, error) {
+	var histories []mdb.OrderChannelHistory
+	err := dao.Mdb.Model(&mdb.OrderChannelHistory{}).Where("trade_id = ?", tradeId).Order("id").Find(&histories).Error
+	return histories, err
 }
 
-// UnLockTransaction 解锁交易
-func UnLockTransaction(tokenWithChainPrefix string, amount float64) error {
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, tokenWithChainPrefix, amount)
-	err := dao.Rdb.Del(ctx, cacheKey).Err()
-	return err
+// CancelOrderById 取消待支付订单，返回是否取消成功
+func CancelOrderById(id uint64) (bool, error) {
+	result := dao.Mdb.Model(&mdb.Orders{}).
+		Where("id = ?", id).
+		Where("status = ?", mdb.StatusWaitPay).
+		Update("status", mdb.StatusCancelled)
+	return result.RowsAffected > 0, result.Error
 }
 
-// IsWalletLocked 查询钱包是否已被锁定（有任意金额的订单）
-// 结果可能不太准确，倾向于已被锁定
-func IsWalletLocked(tokenWithChainPrefix string) bool {
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf(CacheWalletAddressWithAmountToTradeIdKey, tokenWithChainPrefix, "*")
-
-	var cursor uint64
-	// var count uint64
-
-	for {
-		keys, nextCursor, err := dao.Rdb.Scan(ctx, cursor, cacheKey, 1000).Result()
-		if err != nil {
-			fmt.Println("[redis scan]", err)
-			return true // ？
-		}
-
-		for _, key := range keys {
-			// 只统计未过期的键（过期键可能仍然在 SCAN 中被扫到，但已实际失效）
-			ttl, err := dao.Rdb.TTL(ctx, key).Result()
-			if err != nil {
-				return true // ？
-			}
-			// ttl > 0 表示有效
-			if ttl > 0 {
-				// count++
-				// 确定至少有一个锁定的交易
-				return true
-			}
-		}
-
-		cursor = nextCursor
-		if cursor == 0 {
-			break
-		}
-	}
-
-	return false
+// UpdateOrderIsExpirationById 待支付订单的收款钱包仍为 token 时设置订单过期，返回是否设置成功
+func UpdateOrderIsExpirationById(id uint64, token string) (bool, error) {
+	result := dao.Mdb.Model(&mdb.Orders{}).
+		Where("id = ?", id).
+		Where("token = ?", token).
+		Where("status = ?", mdb.StatusWaitPay).
+		Update("status", mdb.StatusExpired)
+	return result.RowsAffected > 0, result.Error
 }
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+// GetEnabledPricingRules 获取商户可匹配链与法币的启用规则
+func GetEnabledPricingRules(merchantId uint64, channel, currency string) ([]mdb.PricingRule, error) {
+	var rules []mdb.PricingRule
+	err := dao.Mdb.Model(&mdb.PricingRule{}).
+		Where("merchant_id = ?", merchantId).
+		Where("status = ?", mdb.PricingRuleStatusEnable).
+		Where("channel in (?, '')", channel).
+		Where("currency in (?, '')", currency).
+		Order("id").
+		Find(&rules).Error
+	return rules, err
+}
+
+// GetPricingRuleById 通过id获取定价规则
+func GetPricingRuleById(id uint64) (*mdb.PricingRule, error) {
+	rule := new(mdb.PricingRule)
+	err := dao.Mdb.Model(rule).Limit(1).Find(rule, id).Error
+	return rule, err
+}
+
+// GetPricingRuleList 按条件分页获取商户的定价规则
+func GetPricingRuleList(merchantId uint64, channel, currency string, page, pageSize int) ([]mdb.PricingRule, int64, error) {
+	var rules []mdb.PricingRule
+	var total int64
+	query := dao.Mdb.Model(&mdb.PricingRule{}).Where("merchant_id = ?", merchantId)
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&rules).Error
+	return rules, total, err
+}
+
+// SavePricingRule 新增或修改定价规则
+func SavePricingRule(rule *mdb.PricingRule) error {
+	return dao.Mdb.Save(rule).Error
+}
+
+// DeletePricingRuleById 通过id删除定价规则
+func DeletePricingRuleById(id uint64) error {
+	return dao.Mdb.Where("id = ?", id).Delete(&mdb.PricingRule{}).Error
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	CacheQuoteKey = "quote:%s" // 报价id : 报价json
+)
+
+// SaveQuote 保存报价
+func SaveQuote(quoteId, quote string, ttl time.Duration) error {
+	ctx := context.Background()
+	return dao.Rdb.Set(ctx, fmt.Sprintf(CacheQuoteKey, quoteId), quote, ttl).Err()
+}
+
+// GetQuote 获取报价，不存在或已过期时返回空
+func GetQuote(quoteId string) (string, error) {
+	ctx := context.Background()
+	quote, err := dao.Rdb.Get(ctx, fmt.Sprintf(CacheQuoteKey, quoteId)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return quote, err
+}
+
+// ConsumeQuote 消耗报价，删除成功才算消耗，并发下单时只有一个请求能消耗同一报价
+func ConsumeQuote(quoteId string) (bool, error) {
+	ctx := context.Background()
+	deleted, err := dao.Rdb.Del(ctx, fmt.Sprintf(CacheQuoteKey, quoteId)).Result()
+	return deleted > 0, err
+}
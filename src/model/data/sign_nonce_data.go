@@ -0,0 +1,20 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+)
+
+var (
+	CacheSignNonceKey = "sign_nonce:%s" // v2签名随机串 -> 使用时间
+)
+
+// AcquireSignNonce 记录v2签名随机串，有效期内随机串已使用过时返回 false
+func AcquireSignNonce(nonce string, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(CacheSignNonceKey, nonce)
+	return dao.Rdb.SetNX(ctx, cacheKey, time.Now().Unix(), ttl).Result()
+}
@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/shopspring/decimal"
+)
+
+// 每个钱包的锁定记录由两部分组成：
+// 有序集合 成员为待支付金额，分值为过期时间(毫秒)，用于判断金额是否被占用、钱包是否有待支付订单以及清理过期记录
+// 哈希表 待支付金额 : 交易号
+// 两者均由 lua 脚本原子维护，时间统一取 redis 服务器时间，避免多实例间的时钟偏差
+// 两个键使用相同的 hash tag，每次执行脚本只操作一个钱包的键，兼容 redis 集群
+var (
+	CacheWalletLockKey      = "wallet_lock:{%s}"       // 钱包（带有链前缀） : 待支付金额 -> 过期时间
+	CacheWalletLockTradeKey = "wallet_lock_trade:{%s}" // 钱包（带有链前缀） : 待支付金额 -> 交易号
+)
+
+// lockCandidateBatchSize 单次查询脚本尝试的候选金额数量
+const lockCandidateBatchSize = 200
+
+const redisNowMillisecondScript = `
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+`
+
+// firstFreeAmountScript 清理钱包过期的锁定记录，返回第一个空闲的候选金额序号(从1开始)，均被占用返回 nil
+// ARGV 为候选金额
+var firstFreeAmountScript = redis.NewScript(`
+redis.replicate_commands()
+` + redisNowMillisecondScript + `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', now)
+if #expired > 0 then
+	redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+	redis.call('HDEL', KEYS[2], unpack(expired))
+end
+for j = 1, #ARGV do
+	if not redis.call('ZSCORE', KEYS[1], ARGV[j]) then
+		return j
+	end
+end
+return false
+`)
+
+// lockTransactionScript 金额空闲时为交易号占用钱包金额，返回是否占用成功
+// ARGV[1] 交易号，ARGV[2] 锁定时长(毫秒)，ARGV[3] 金额
+var lockTransactionScript = redis.NewScript(`
+redis.replicate_commands()
+` + redisNowMillisecondScript + `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[3])
+if score and tonumber(score) > now then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[3])
+redis.call('HSET', KEYS[2], ARGV[3], ARGV[1])
+local last = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+local keyExpireAt = math.ceil(tonumber(last[2]))
+redis.call('PEXPIREAT', KEYS[1], keyExpireAt)
+redis.call('PEXPIREAT', KEYS[2], keyExpireAt)
+return 1
+`)
+
+// unLockTransactionScript 释放金额，仅在金额仍被该交易占用时释放，避免误释放已被其他订单占用的金额
+// ARGV[1] 金额，ARGV[2] 交易号
+var unLockTransactionScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1
+`)
+
+// getTradeIdScript 获取未过期金额对应的交易号
+var getTradeIdScript = redis.NewScript(redisNowMillisecondScript + `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score or tonumber(score) <= now then
+	return false
+end
+return redis.call('HGET', KEYS[2], ARGV[1])
+`)
+
+// isWalletLockedScript 钱包是否有未过期的锁定记录
+var isWalletLockedScript = redis.NewScript(redisNowMillisecondScript + `
+return redis.call('ZCOUNT', KEYS[1], '(' .. now, '+inf')
+`)
+
+// walletLockKeys 钱包锁定记录的键
+func walletLockKeys(tokenWithChainPrefix string) []string {
+	return []string{
+		fmt.Sprintf(CacheWalletLockKey, tokenWithChainPrefix),
+		fmt.Sprintf(CacheWalletLockTradeKey, tokenWithChainPrefix),
+	}
+}
+
+// formatLockAmount 金额统一格式，保证锁定与查询使用相同的成员
+func formatLockAmount(amount float64) string {
+	return decimal.NewFromFloat(amount).String()
+}
+
+// GetTradeIdByWalletAddressAndAmount 通过钱包地址，支付金额获取交易号
+func GetTradeIdByWalletAddressAndAmount(tokenWithChainPrefix string, amount float64) (string, error) {
+	ctx := context.Background()
+	result, err := getTradeIdScript.Run(ctx, dao.Rdb, walletLockKeys(tokenWithChainPrefix), formatLockAmount(amount)).Text()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// LockTransaction 锁定交易，钱包金额已被其他交易占用时返回 false
+func LockTransaction(tokenWithChainPrefix, tradeId string, amount float64, expirationTime time.Duration) (bool, error) {
+	index, _, err := LockFirstAvailableTransaction([]string{tokenWithChainPrefix}, tradeId, []float64{amount}, expirationTime)
+	if err != nil {
+		return false, err
+	}
+	return index == 0, nil
+}
+
+// LockFirstAvailableTransaction 按钱包顺序依次尝试，原子占用第一个有空闲金额的钱包
+// 钱包顺序即分配策略的结果，优先于金额偏移；同一钱包内按候选金额顺序占用第一个空闲金额，占用时已被其他交易抢占则重新查询
+// 返回占用成功的钱包下标与金额，全部被占用时下标为 -1
+func LockFirstAvailableTransaction(tokenWithChainPrefixList []string, tradeId string, amounts []float64, expirationTime time.Duration) (int, float64, error) {
+	ctx := context.Background()
+	// 分批执行，避免单次脚本执行时间过长阻塞 redis
+	var batches [][]interface{}
+	for start := 0; start < len(amounts); start += lockCandidateBatchSize {
+		end := start + lockCandidateBatchSize
+		if end > len(amounts) {
+			end = len(amounts)
+		}
+		var args []interface{}
+		for _, amount := range amounts[start:end] {
+			args = append(args, formatLockAmount(amount))
+		}
+		batches = append(batches, args)
+	}
+	for walletIndex, tokenWithChainPrefix := range tokenWithChainPrefixList {
+		keys := walletLockKeys(tokenWithChainPrefix)
+		for batchIndex, args := range batches {
+			for {
+				amountIndex, err := firstFreeAmountScript.Run(ctx, dao.Rdb, keys, args...).Int()
+				if err == redis.Nil {
+					break
+				}
+				if err != nil {
+					return -1, 0, err
+				}
+				locked, err := lockTransactionScript.Run(ctx, dao.Rdb, keys, tradeId, expirationTime.Milliseconds(), args[amountIndex-1]).Int()
+				if err != nil {
+					return -1, 0, err
+				}
+				if locked == 1 {
+					return walletIndex, amounts[batchIndex*lockCandidateBatchSize+amountIndex-1], nil
+				}
+			}
+		}
+	}
+	return -1, 0, nil
+}
+
+// UnLockTransaction 解锁交易，金额已被其他交易占用时不做处理
+func UnLockTransaction(tokenWithChainPrefix, tradeId string, amount float64) error {
+	ctx := context.Background()
+	return unLockTransactionScript.Run(ctx, dao.Rdb, walletLockKeys(tokenWithChainPrefix), formatLockAmount(amount), tradeId).Err()
+}
+
+// UnLockOrderTransactions 解锁订单占用的全部钱包金额，包括切换付款网络前保留的原钱包金额
+func UnLockOrderTransactions(order *mdb.Orders) error {
+	if order.TokenWithChainPrefix != "" {
+		if err := UnLockTransaction(order.TokenWithChainPrefix, order.TradeId, order.ActualAmount); err != nil {
+			return err
+		}
+	}
+	histories, err := GetOrderChannelHistory(order.TradeId)
+	if err != nil {
+		return err
+	}
+	for _, history := range histories {
+		if history.FromToken == "" {
+			continue
+		}
+		if err = UnLockTransaction(history.FromToken, order.TradeId, history.FromActualAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsWalletLocked 查询钱包是否已被锁定（有任意金额的未过期订单）
+// 查询出错时倾向于已被锁定，避免漏扫
+func IsWalletLocked(tokenWithChainPrefix string) bool {
+	ctx := context.Background()
+	count, err := isWalletLockedScript.Run(ctx, dao.Rdb, walletLockKeys(tokenWithChainPrefix)).Int()
+	if err != nil {
+		log.Sugar.Error(err)
+		return true
+	}
+	return count > 0
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	CacheUsdtRateKey            = "usdt_rate:%s"           // 法币 : 聚合后的汇率json
+	CacheUsdtRateRefreshLockKey = "usdt_rate_refresh_lock" // 汇率刷新锁，同一周期只有一个实例刷新
+)
+
+// AcquireUsdtRateRefreshLock 获取汇率刷新锁，锁在 ttl 后自动释放，已被其他实例持有时返回 false
+func AcquireUsdtRateRefreshLock(ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	return dao.Rdb.SetNX(ctx, CacheUsdtRateRefreshLockKey, time.Now().Unix(), ttl).Result()
+}
+
+// SaveUsdtRate 保存聚合后的汇率，不设置过期时间，是否过期由汇率更新时间判断
+func SaveUsdtRate(currency, rate string) error {
+	ctx := context.Background()
+	return dao.Rdb.Set(ctx, fmt.Sprintf(CacheUsdtRateKey, currency), rate, 0).Err()
+}
+
+// GetUsdtRate 获取聚合后的汇率，不存在时返回空
+func GetUsdtRate(currency string) (string, error) {
+	ctx := context.Background()
+	rate, err := dao.Rdb.Get(ctx, fmt.Sprintf(CacheUsdtRateKey, currency)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return rate, err
+}
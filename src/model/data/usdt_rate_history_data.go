@@ -0,0 +1,33 @@
+package data
+
+import (
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+// CreateUsdtRateHistory 保存汇率历史
+func CreateUsdtRateHistory(history *mdb.UsdtRateHistory) error {
+	return dao.Mdb.Create(history).Error
+}
+
+// GetUsdtRateHistory 分页获取汇率历史
+func GetUsdtRateHistory(currency, startTime, endTime string, page, pageSize int) ([]mdb.UsdtRateHistory, int64, error) {
+	var histories []mdb.UsdtRateHistory
+	var total int64
+	query := dao.Mdb.Model(&mdb.UsdtRateHistory{})
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+	if startTime != "" {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if endTime != "" {
+		query = query.Where("created_at <= ?", endTime)
+	}
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&histories).Error
+	return histories, total, err
+}
@@ -1,13 +1,50 @@
 package data
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/assimon/luuu/model"
 	"github.com/assimon/luuu/model/dao"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/address"
 	"github.com/assimon/luuu/util/constant"
+	"github.com/golang-module/carbon/v2"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	CacheWalletRoundRobinKey = "wallet_select:round_robin:%s" // 轮询计数器 : 链
+	CacheWalletLastUsedKey   = "wallet_select:last_used:%s"   // 钱包最近使用时间 : 链
 )
 
-// AddWalletAddress 创建钱包
-func AddWalletAddress(token string, channel string) (*mdb.WalletAddress, error) {
+// WalletInfoMaxLength 钱包可编辑信息的最大长度
+var WalletInfoMaxLength = map[string]int{
+	"label":              64,
+	"note":               255,
+	"group_name":         64,
+	"checkout_message":   255,
+	"weight":             10,
+	"daily_volume_limit": 20,
+	"daily_order_limit":  10,
+}
+
+// WalletDailyUsage 钱包当日用量
+type WalletDailyUsage struct {
+	Token       string  `gorm:"column:token"`
+	OrderCount  int     `gorm:"column:order_count"`
+	OrderVolume float64 `gorm:"column:order_volume"`
+}
+
+// AddWalletAddress 为商户创建钱包，同一钱包只能属于一个商户
+func AddWalletAddress(merchantId uint64, token string, channel string) (*mdb.WalletAddress, error) {
+	token, err := NormalizeWalletAddress(token, channel)
+	if err != nil {
+		return nil, err
+	}
 	exist, err := GetWalletAddressByToken(token, channel)
 	if err != nil {
 		return nil, err
@@ -16,14 +53,32 @@ func AddWalletAddress(token string, channel string) (*mdb.WalletAddress, error)
 		return nil, constant.WalletAddressAlreadyExists
 	}
 	walletAddress := &mdb.WalletAddress{
-		Token:   token,
-		Channel: channel,
-		Status:  mdb.TokenStatusEnable,
+		MerchantId: merchantId,
+		Token:      token,
+		Channel:    channel,
+		Status:     mdb.TokenStatusEnable,
 	}
 	err = dao.Mdb.Create(walletAddress).Error
 	return walletAddress, err
 }
 
+// NormalizeWalletAddress 按链校验钱包地址，并返回统一格式的地址
+func NormalizeWalletAddress(token string, channel string) (string, error) {
+	switch channel {
+	case model.ChainNameTRC20:
+		if err := address.ValidateTronAddress(token); err != nil {
+			return "", err
+		}
+		return token, nil
+	case model.ChainNamePolygonPOS, model.ChainNameBSC, model.ChainNameAVAXC, model.ChainNameETH, model.ChainNameArbitrum:
+		return address.NormalizeEvmAddress(token)
+	case model.ChainNameAptos:
+		return address.NormalizeAptosAddress(token)
+	default:
+		return "", constant.ChannelNotSupportErr
+	}
+}
+
 func GetWalletAddressByToken(token, channel string) (*mdb.WalletAddress, error) {
 	walletAddress := new(mdb.WalletAddress)
 	err := dao.Mdb.Model(walletAddress).Limit(1).Find(walletAddress, "token = ? AND channel = ?", token, channel).Error
@@ -50,6 +105,38 @@ func GetAvailableWallet(channel string) ([]mdb.WalletAddress, error) {
 	return WalletAddressList, err
 }
 
+// GetMerchantAvailableWallet 获得商户所有可用的钱包地址
+func GetMerchantAvailableWallet(merchantId uint64, channel string) ([]mdb.WalletAddress, error) {
+	var WalletAddressList []mdb.WalletAddress
+	err := dao.Mdb.Model(WalletAddressList).
+		Where("merchant_id = ?", merchantId).
+		Where("channel = ? and status = ?", channel, mdb.TokenStatusEnable).
+		Find(&WalletAddressList).Error
+	return WalletAddressList, err
+}
+
+// CountAvailableWalletByChannel 统计商户各链启用的钱包数量
+func CountAvailableWalletByChannel(merchantId uint64) (map[string]int64, error) {
+	var rows []struct {
+		Channel string
+		Total   int64
+	}
+	err := dao.Mdb.Model(&mdb.WalletAddress{}).
+		Select("channel, count(*) as total").
+		Where("merchant_id = ?", merchantId).
+		Where("status = ?", mdb.TokenStatusEnable).
+		Group("channel").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		result[row.Channel] = row.Total
+	}
+	return result, nil
+}
+
 // GetAllWalletAddress 获得所有钱包地址
 func GetAllWalletAddress() ([]mdb.WalletAddress, error) {
 	var WalletAddressList []mdb.WalletAddress
@@ -57,8 +144,130 @@ func GetAllWalletAddress() ([]mdb.WalletAddress, error) {
 	return WalletAddressList, err
 }
 
+// GetWalletAddressList 按条件分页获取商户的钱包
+func GetWalletAddressList(merchantId uint64, channel string, status int, label, groupName string, page, pageSize int) ([]mdb.WalletAddress, int64, error) {
+	var walletAddressList []mdb.WalletAddress
+	var total int64
+	query := dao.Mdb.Model(&mdb.WalletAddress{}).Where("merchant_id = ?", merchantId)
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	if label != "" {
+		query = query.Where("label like ?", "%"+label+"%")
+	}
+	if groupName != "" {
+		query = query.Where("group_name = ?", groupName)
+	}
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&walletAddressList).Error
+	return walletAddressList, total, err
+}
+
+// UpdateWalletAddressInfo 修改钱包标签、备注、分组、收银台提示、权重与每日上限等信息
+func UpdateWalletAddressInfo(id uint64, info map[string]string) error {
+	values := make(map[string]interface{}, len(info))
+	for field, value := range info {
+		maxLength, ok := WalletInfoMaxLength[field]
+		if !ok {
+			return constant.ParamsMarshalErr
+		}
+		if utf8.RuneCountInString(value) > maxLength {
+			return constant.WalletInfoTooLongErr
+		}
+		fieldValue, err := walletInfoValue(field, value)
+		if err != nil {
+			return err
+		}
+		values[field] = fieldValue
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return dao.Mdb.Model(&mdb.WalletAddress{}).Where("id = ?", id).Updates(values).Error
+}
+
+// walletInfoValue 权重与每日上限须为不小于0的数字，空字符串为0(权重0按1分配，上限0为不限制)
+func walletInfoValue(field, value string) (interface{}, error) {
+	switch field {
+	case "weight", "daily_order_limit":
+		if value == "" {
+			return 0, nil
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 0 {
+			return nil, constant.WalletInfoNumberErr
+		}
+		return number, nil
+	case "daily_volume_limit":
+		if value == "" {
+			return 0, nil
+		}
+		number, err := decimal.NewFromString(value)
+		if err != nil || number.IsNegative() {
+			return nil, constant.WalletInfoNumberErr
+		}
+		return number.InexactFloat64(), nil
+	}
+	return value, nil
+}
+
 // ChangeWalletAddressStatus 启用禁用钱包
 func ChangeWalletAddressStatus(id uint64, status int) error {
 	err := dao.Mdb.Model(&mdb.WalletAddress{}).Where("id = ?", id).Update("status", status).Error
 	return err
 }
+
+// GetWalletDailyUsage 统计钱包（带有链前缀）当日的订单数与金额，不含已过期订单
+func GetWalletDailyUsage(tokenWithChainPrefixList []string) (map[string]WalletDailyUsage, error) {
+	var usages []WalletDailyUsage
+	err := dao.Mdb.Model(&mdb.Orders{}).
+		Select("token, count(*) as order_count, sum(actual_amount) as order_volume").
+		Where("token in ?", tokenWithChainPrefixList).
+		Where("status in ?", []int{mdb.StatusWaitPay, mdb.StatusPaySuccess}).
+		Where("created_at >= ?", carbon.Now().StartOfDay().ToDateTimeString()).
+		Group("token").
+		Scan(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]WalletDailyUsage, len(usages))
+	for _, usage := range usages {
+		result[usage.Token] = usage
+	}
+	return result, nil
+}
+
+// IncrWalletRoundRobin 递增并返回链的轮询计数
+func IncrWalletRoundRobin(channel string) (int64, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(CacheWalletRoundRobinKey, channel)
+	return dao.Rdb.Incr(ctx, cacheKey).Result()
+}
+
+// GetWalletLastUsed 获取链下各钱包最近一次分配的时间戳(毫秒)
+func GetWalletLastUsed(channel string) (map[string]int64, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(CacheWalletLastUsedKey, channel)
+	values, err := dao.Rdb.HGetAll(ctx, cacheKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int64, len(values))
+	for token, value := range values {
+		result[token], _ = strconv.ParseInt(value, 10, 64)
+	}
+	return result, nil
+}
+
+// TouchWalletLastUsed 记录钱包最近一次分配的时间
+func TouchWalletLastUsed(channel, token string) error {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(CacheWalletLastUsedKey, channel)
+	return dao.Rdb.HSet(ctx, cacheKey, token, time.Now().UnixNano()/1e6).Err()
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/assimon/luuu/model/dao"
+	"github.com/assimon/luuu/model/mdb"
+)
+
+var (
+	CacheWalletAlertKey = "wallet_alert:%s:%s" // 告警类型_钱包（带有链前缀） : 告警静默标记
+)
+
+// CreateWalletBalance 保存钱包余额快照
+func CreateWalletBalance(balance *mdb.WalletBalance) error {
+	return dao.Mdb.Create(balance).Error
+}
+
+// GetLatestWalletBalances 获取每个钱包最新的余额快照
+func GetLatestWalletBalances() ([]mdb.WalletBalance, error) {
+	var balances []mdb.WalletBalance
+	walletIds := dao.Mdb.Model(&mdb.WalletAddress{}).Select("id")
+	latestIds := dao.Mdb.Model(&mdb.WalletBalance{}).Select("max(id)").Where("wallet_id in (?)", walletIds).Group("wallet_id")
+	err := dao.Mdb.Model(&mdb.WalletBalance{}).Where("id in (?)", latestIds).Order("wallet_id").Find(&balances).Error
+	return balances, err
+}
+
+// GetMerchantLatestWalletBalances 获取商户每个钱包最新的余额快照
+func GetMerchantLatestWalletBalances(merchantId uint64) ([]mdb.WalletBalance, error) {
+	var balances []mdb.WalletBalance
+	walletIds := dao.Mdb.Model(&mdb.WalletAddress{}).Select("id").Where("merchant_id = ?", merchantId)
+	latestIds := dao.Mdb.Model(&mdb.WalletBalance{}).Select("max(id)").Where("wallet_id in (?)", walletIds).Group("wallet_id")
+	err := dao.Mdb.Model(&mdb.WalletBalance{}).Where("id in (?)", latestIds).Order("wallet_id").Find(&balances).Error
+	return balances, err
+}
+
+// GetLatestWalletBalanceByWalletId 获取钱包最新的余额快照
+func GetLatestWalletBalanceByWalletId(walletId uint64) (*mdb.WalletBalance, error) {
+	balance := new(mdb.WalletBalance)
+	err := dao.Mdb.Model(balance).Where("wallet_id = ?", walletId).Order("id desc").Limit(1).Find(balance).Error
+	return balance, err
+}
+
+// GetWalletBalanceHistory 分页获取钱包余额历史
+func GetWalletBalanceHistory(walletId uint64, startTime, endTime string, page, pageSize int) ([]mdb.WalletBalance, int64, error) {
+	var balances []mdb.WalletBalance
+	var total int64
+	query := dao.Mdb.Model(&mdb.WalletBalance{}).Where("wallet_id = ?", walletId)
+	if startTime != "" {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if endTime != "" {
+		query = query.Where("created_at <= ?", endTime)
+	}
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&balances).Error
+	return balances, total, err
+}
+
+// GetPaidOrderAmountByBlockIds 统计钱包下指定区块交易对应的支付成功订单金额
+func GetPaidOrderAmountByBlockIds(tokenWithChainPrefix string, blockIds []string) (float64, error) {
+	var amount float64
+	if len(blockIds) == 0 {
+		return 0, nil
+	}
+	err := dao.Mdb.Model(&mdb.Orders{}).
+		Select("coalesce(sum(actual_amount), 0)").
+		Where("token = ?", tokenWithChainPrefix).
+		Where("status = ?", mdb.StatusPaySuccess).
+		Where("block_transaction_id in ?", blockIds).
+		Scan(&amount).Error
+	return amount, err
+}
+
+// AcquireWalletAlert 获取告警发送权，静默期内同一钱包的同类告警只发送一次
+func AcquireWalletAlert(alertType, tokenWithChainPrefix string, silence time.Duration) (bool, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf(CacheWalletAlertKey, alertType, tokenWithChainPrefix)
+	return dao.Rdb.SetNX(ctx, cacheKey, time.Now().Unix(), silence).Result()
+}
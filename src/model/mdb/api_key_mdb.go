@@ -0,0 +1,55 @@
+package mdb
+
+import (
+	"strings"
+
+	"github.com/golang-module/carbon/v2"
+)
+
+const (
+	ApiKeyStatusEnable  = 1
+	ApiKeyStatusDisable = 2
+
+	ApiKeyScopeCreate = "create" // 创建订单、报价、切换付款网络
+	ApiKeyScopeQuery  = "query"  // 查询汇率、链列表、回调记录
+	ApiKeyScopeCancel = "cancel" // 取消订单
+	ApiKeyScopeAdmin  = "admin"  // 管理接口，拥有全部权限
+)
+
+// ApiKeyScopes 支持的权限
+var ApiKeyScopes = []string{ApiKeyScopeCreate, ApiKeyScopeQuery, ApiKeyScopeCancel, ApiKeyScopeAdmin}
+
+// ApiKey 接口密钥，用于接口签名与该密钥所建订单的回调签名
+type ApiKey struct {
+	MerchantId uint64      `gorm:"column:merchant_id" json:"merchant_id"` //  所属商户id，0为默认商户
+	KeyId      string      `gorm:"column:key_id" json:"key_id"`           //  密钥id
+	Secret     string      `gorm:"column:secret" json:"-"`                //  签名密钥
+	Name       string      `gorm:"column:name" json:"name"`               //  名称
+	Scopes     string      `gorm:"column:scopes" json:"scopes"`           //  权限，逗号分隔 create query cancel admin
+	Status     int         `gorm:"column:status" json:"status"`           //  1：启用 2：禁用
+	ExpiredAt  carbon.Time `gorm:"column:expired_at" json:"expired_at"`   //  过期时间，为空永不过期
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (a *ApiKey) TableName() string {
+	return "api_key"
+}
+
+// HasScope 是否拥有权限，admin 拥有全部权限
+func (a *ApiKey) HasScope(scope string) bool {
+	for _, item := range strings.Split(a.Scopes, ",") {
+		if item == scope || item == ApiKeyScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable 是否已启用且未过期
+func (a *ApiKey) IsUsable() bool {
+	if a.Status != ApiKeyStatusEnable {
+		return false
+	}
+	return a.ExpiredAt.IsZero() || a.ExpiredAt.Gt(carbon.Now())
+}
@@ -0,0 +1,51 @@
+package mdb
+
+const (
+	CallbackLogStatusSuccess = 1
+	CallbackLogStatusFailed  = 2
+
+	CallbackLogBodyMaxLength = 2048 // 响应内容最大记录长度
+)
+
+// CallbackLog 异步回调投递记录
+type CallbackLog struct {
+	MerchantId   uint64 `gorm:"column:merchant_id" json:"merchant_id"`     //  所属商户id
+	TradeId      string `gorm:"column:trade_id" json:"trade_id"`           //  epusdt订单号
+	OrderId      string `gorm:"column:order_id" json:"order_id"`           //  客户交易id
+	EventType    string `gorm:"column:event_type" json:"event_type"`       //  事件类型
+	Attempt      int    `gorm:"column:attempt" json:"attempt"`             //  第几次回调
+	NotifyUrl    string `gorm:"column:notify_url" json:"notify_url"`       //  回调地址
+	Payload      string `gorm:"column:payload" json:"payload"`             //  请求内容
+	Signature    string `gorm:"column:signature" json:"signature"`         //  签名
+	StatusCode   int    `gorm:"column:status_code" json:"status_code"`     //  http状态码，请求失败为0
+	ResponseBody string `gorm:"column:response_body" json:"response_body"` //  响应内容，超出部分截断
+	Duration     int64  `gorm:"column:duration" json:"duration"`           //  耗时(毫秒)
+	Error        string `gorm:"column:error" json:"error"`                 //  错误信息
+	Status       int    `gorm:"column:status" json:"status"`               //  1：成功 2：失败
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (c *CallbackLog) TableName() string {
+	return "callback_log"
+}
+
+const (
+	CallbackResendSourceApi      = "api"      // 管理接口重新回调
+	CallbackResendSourceTelegram = "telegram" // 机器人重新回调
+)
+
+// CallbackResendLog 手动重新回调记录
+type CallbackResendLog struct {
+	TradeId             string `gorm:"column:trade_id" json:"trade_id"`                           //  epusdt订单号
+	Operator            string `gorm:"column:operator" json:"operator"`                           //  操作人
+	Source              string `gorm:"column:source" json:"source"`                               //  操作来源 api telegram
+	PrevCallbackNum     int    `gorm:"column:prev_callback_num" json:"prev_callback_num"`         //  重新回调前的回调次数
+	PrevCallbackConfirm int    `gorm:"column:prev_callback_confirm" json:"prev_callback_confirm"` //  重新回调前的回调状态
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (c *CallbackResendLog) TableName() string {
+	return "callback_resend_log"
+}
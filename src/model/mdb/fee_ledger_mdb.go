@@ -0,0 +1,21 @@
+package mdb
+
+// FeeLedger 手续费台账，每笔支付成功的订单一条，记录计提时应用的规则
+type FeeLedger struct {
+	MerchantId  uint64  `gorm:"column:merchant_id" json:"merchant_id"`   //  所属商户id
+	TradeId     string  `gorm:"column:trade_id" json:"trade_id"`         //  epusdt订单号
+	OrderId     string  `gorm:"column:order_id" json:"order_id"`         //  客户交易id
+	Channel     string  `gorm:"column:channel" json:"channel"`           //  收款链
+	FeeRuleId   uint64  `gorm:"column:fee_rule_id" json:"fee_rule_id"`   //  应用的手续费规则id，0为未匹配规则
+	Percent     float64 `gorm:"column:percent" json:"percent"`           //  应用的百分比
+	FixedFee    float64 `gorm:"column:fixed_fee" json:"fixed_fee"`       //  应用的固定手续费(usdt)
+	GrossAmount float64 `gorm:"column:gross_amount" json:"gross_amount"` //  订单实收金额(usdt)
+	FeeAmount   float64 `gorm:"column:fee_amount" json:"fee_amount"`     //  手续费(usdt)
+	NetAmount   float64 `gorm:"column:net_amount" json:"net_amount"`     //  扣除手续费后的净额(usdt)
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (f *FeeLedger) TableName() string {
+	return "fee_ledger"
+}
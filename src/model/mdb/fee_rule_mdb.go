@@ -0,0 +1,21 @@
+package mdb
+
+const (
+	FeeRuleStatusEnable  = 1
+	FeeRuleStatusDisable = 2
+)
+
+// FeeRule 平台手续费规则，订单支付成功时按规则向商户计提手续费，链为空时匹配所有链
+type FeeRule struct {
+	MerchantId uint64  `gorm:"column:merchant_id" json:"merchant_id"` //  所属商户id，0为默认商户
+	Channel    string  `gorm:"column:channel" json:"channel"`         //  链类，为空匹配所有链
+	Percent    float64 `gorm:"column:percent" json:"percent"`         //  按实收金额计提的百分比
+	FixedFee   float64 `gorm:"column:fixed_fee" json:"fixed_fee"`     //  每笔固定手续费(usdt)
+	Status     int     `gorm:"column:status" json:"status"`           //  1:启用 2:禁用
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (f *FeeRule) TableName() string {
+	return "fee_rule"
+}
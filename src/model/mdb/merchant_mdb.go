@@ -0,0 +1,40 @@
+package mdb
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	MerchantStatusEnable  = 1
+	MerchantStatusDisable = 2
+
+	DefaultMerchantId = 0 // 默认商户，使用全局配置，升级前的数据均属于默认商户
+)
+
+// Merchant 商户，api密钥、钱包、定价规则与订单均按商户隔离
+type Merchant struct {
+	Name         string `gorm:"column:name" json:"name"`                   //  名称
+	Status       int    `gorm:"column:status" json:"status"`               //  1:启用 2:禁用
+	NotifyUrl    string `gorm:"column:notify_url" json:"notify_url"`       //  默认异步回调地址，创建订单未传时使用
+	NotifyEvents string `gorm:"column:notify_events" json:"notify_events"` //  默认额外开启回调的事件，逗号分隔，为空使用全局配置
+	TgChatIds    string `gorm:"column:tg_chat_ids" json:"tg_chat_ids"`     //  接收该商户通知的telegram会话id，逗号分隔
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (m *Merchant) TableName() string {
+	return "merchant"
+}
+
+// GetTgChatIds 接收该商户通知的telegram会话id
+func (m *Merchant) GetTgChatIds() []int64 {
+	var chatIds []int64
+	for _, item := range strings.Split(m.TgChatIds, ",") {
+		chatId, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+		if err == nil && chatId != 0 {
+			chatIds = append(chatIds, chatId)
+		}
+	}
+	return chatIds
+}
@@ -0,0 +1,25 @@
+package mdb
+
+import "github.com/golang-module/carbon/v2"
+
+const (
+	ChannelSwitchSourceApi      = "api"      // 商户接口切换
+	ChannelSwitchSourceCheckout = "checkout" // 客户在收银台切换
+)
+
+// OrderChannelHistory 订单付款网络切换记录
+type OrderChannelHistory struct {
+	TradeId          string      `gorm:"column:trade_id" json:"trade_id"`                     //  epusdt订单号
+	FromToken        string      `gorm:"column:from_token" json:"from_token"`                 //  切换前的钱包地址（带有链前缀）
+	FromActualAmount float64     `gorm:"column:from_actual_amount" json:"from_actual_amount"` //  切换前需要支付的金额
+	FromExpiredAt    carbon.Time `gorm:"column:from_expired_at" json:"from_expired_at"`       //  切换前的钱包金额占用到期时间，到期前向原地址的转账仍可匹配订单
+	ToToken          string      `gorm:"column:to_token" json:"to_token"`                     //  切换后的钱包地址（带有链前缀）
+	ToActualAmount   float64     `gorm:"column:to_actual_amount" json:"to_actual_amount"`     //  切换后需要支付的金额
+	Source           string      `gorm:"column:source" json:"source"`                         //  切换来源 api checkout
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (o *OrderChannelHistory) TableName() string {
+	return "order_channel_history"
+}
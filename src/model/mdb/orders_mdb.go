@@ -1,25 +1,60 @@
 package mdb
 
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/golang-module/carbon/v2"
+)
+
+const (
+	StatusWaitPay       = 1
+	StatusPaySuccess    = 2
+	StatusExpired       = 3
+	StatusCancelled     = 4
+	CallBackConfirmOk   = 1
+	CallBackConfirmNo   = 2
+	CallBackConfirmDead = 3 // 重试全部失败，不再自动回调
+)
+
+// 订单回调事件，支付成功事件始终回调，其余事件需商户开启
 const (
-	StatusWaitPay     = 1
-	StatusPaySuccess  = 2
-	StatusExpired     = 3
-	CallBackConfirmOk = 1
-	CallBackConfirmNo = 2
+	OrderEventPaid       = "order.paid"       // 支付成功
+	OrderEventExpired    = "order.expired"    // 已过期
+	OrderEventCancelled  = "order.cancelled"  // 已取消
+	OrderEventConfirming = "order.confirming" // 已收到转账，等待区块确认
 )
 
+// OptionalOrderEvents 可按需开启回调的事件
+var OptionalOrderEvents = []string{OrderEventExpired, OrderEventCancelled, OrderEventConfirming}
+
 type Orders struct {
-	TradeId              string  `gorm:"column:trade_id" json:"trade_id"`                         //  epusdt订单号
-	OrderId              string  `gorm:"column:order_id" json:"order_id"`                         //  客户交易id
-	BlockTransactionId   string  `gorm:"column:block_transaction_id" json:"block_transaction_id"` // 区块id
-	Amount               float64 `gorm:"column:amount" json:"amount"`                             //  订单金额，保留4位小数
-	ActualAmount         float64 `gorm:"column:actual_amount" json:"actual_amount"`               //  订单实际需要支付的金额，保留4位小数
-	TokenWithChainPrefix string  `gorm:"column:token" json:"token"`                               //  所属钱包地址（带有链前缀）
-	Status               int     `gorm:"column:status" json:"status"`                             //  1：等待支付，2：支付成功，3：已过期
-	NotifyUrl            string  `gorm:"column:notify_url" json:"notify_url"`                     //  异步回调地址
-	RedirectUrl          string  `gorm:"column:redirect_url" json:"redirect_url"`                 //  同步回调地址
-	CallbackNum          int     `gorm:"column:callback_num" json:"callback_num"`                 // 回调次数
-	CallBackConfirm      int     `gorm:"column:callback_confirm" json:"callback_confirm"`         // 回调是否已确认 1是 2否
+	MerchantId           uint64      `gorm:"column:merchant_id" json:"merchant_id"`                   //  所属商户id，0为默认商户
+	TradeId              string      `gorm:"column:trade_id" json:"trade_id"`                         //  epusdt订单号
+	OrderId              string      `gorm:"column:order_id" json:"order_id"`                         //  客户交易id
+	BlockTransactionId   string      `gorm:"column:block_transaction_id" json:"block_transaction_id"` // 区块id
+	Amount               float64     `gorm:"column:amount" json:"amount"`                             //  订单金额，保留4位小数
+	ActualAmount         float64     `gorm:"column:actual_amount" json:"actual_amount"`               //  订单实际需要支付的金额，保留4位小数
+	TokenWithChainPrefix string      `gorm:"column:token" json:"token"`                               //  所属钱包地址（带有链前缀）
+	Status               int         `gorm:"column:status" json:"status"`                             //  1：等待支付，2：支付成功，3：已过期，4：已取消
+	NotifyUrl            string      `gorm:"column:notify_url" json:"notify_url"`                     //  异步回调地址
+	RedirectUrl          string      `gorm:"column:redirect_url" json:"redirect_url"`                 //  同步回调地址
+	CallbackNum          int         `gorm:"column:callback_num" json:"callback_num"`                 // 回调次数
+	CallBackConfirm      int         `gorm:"column:callback_confirm" json:"callback_confirm"`         // 回调是否已确认 1是 2否 3重试全部失败
+	Currency             string      `gorm:"column:currency" json:"currency"`                         //  订单金额的法币
+	RawRate              float64     `gorm:"column:raw_rate" json:"raw_rate"`                         //  下单时的原始汇率
+	RateSource           string      `gorm:"column:rate_source" json:"rate_source"`                   //  汇率来源 request forced median:汇率源
+	RateAt               carbon.Time `gorm:"column:rate_at" json:"rate_at"`                           //  汇率的更新时间
+	PricingRuleId        uint64      `gorm:"column:pricing_rule_id" json:"pricing_rule_id"`           //  应用的定价规则id，0为未应用
+	MarkupPercent        float64     `gorm:"column:markup_percent" json:"markup_percent"`             //  应用的加价百分比
+	FixedFee             float64     `gorm:"column:fixed_fee" json:"fixed_fee"`                       //  应用的固定手续费(usdt)
+	Rounding             string      `gorm:"column:rounding" json:"rounding"`                         //  应用的舍入方式
+	ExpiredAt            carbon.Time `gorm:"column:expired_at" json:"expired_at"`                     //  过期时间，切换付款网络时重置
+	NotifyEvents         string      `gorm:"column:notify_events" json:"notify_events"`               //  额外开启回调的事件，逗号分隔
+	ApiKeyId             string      `gorm:"column:api_key_id" json:"api_key_id"`                     //  创建订单的api密钥id，回调使用该密钥签名，为空使用api_auth_token
+	CustomerEmail        string      `gorm:"column:customer_email" json:"customer_email"`             //  客户邮箱
+	CustomerName         string      `gorm:"column:customer_name" json:"customer_name"`               //  客户名称
+	Metadata             string      `gorm:"column:metadata" json:"metadata"`                         //  商户自定义数据json对象，原样返回
 	BaseModel
 }
 
@@ -27,3 +62,49 @@ type Orders struct {
 func (o *Orders) TableName() string {
 	return "orders"
 }
+
+// GetExpiredAt 订单过期时间，未记录过期时间的历史订单按创建时间计算
+func (o *Orders) GetExpiredAt(expirationMinutes int) carbon.Carbon {
+	if !o.ExpiredAt.IsZero() {
+		return o.ExpiredAt.Carbon
+	}
+	return o.CreatedAt.AddMinutes(expirationMinutes)
+}
+
+// GetMetadata 商户自定义数据，未设置时返回nil
+func (o *Orders) GetMetadata() json.RawMessage {
+	if o.Metadata == "" {
+		return nil
+	}
+	return json.RawMessage(o.Metadata)
+}
+
+// IsNotifyEventEnabled 订单是否需要回调该事件
+func (o *Orders) IsNotifyEventEnabled(event string) bool {
+	if event == OrderEventPaid {
+		return true
+	}
+	for _, item := range strings.Split(o.NotifyEvents, ",") {
+		if item == event {
+			return true
+		}
+	}
+	return false
+}
+
+// CallbackEvent 订单当前状态需要回调的最终事件，无需回调时返回空
+func (o *Orders) CallbackEvent() string {
+	event := ""
+	switch o.Status {
+	case StatusPaySuccess:
+		event = OrderEventPaid
+	case StatusExpired:
+		event = OrderEventExpired
+	case StatusCancelled:
+		event = OrderEventCancelled
+	}
+	if event == "" || !o.IsNotifyEventEnabled(event) {
+		return ""
+	}
+	return event
+}
@@ -0,0 +1,30 @@
+package mdb
+
+const (
+	PricingRuleStatusEnable  = 1
+	PricingRuleStatusDisable = 2
+
+	RoundingHalfUp   = "half_up"   // 四舍五入
+	RoundingUp       = "up"        // 向上取整
+	RoundingDown     = "down"      // 向下取整
+	RoundingHalfEven = "half_even" // 银行家舍入
+)
+
+// PricingRule 定价规则，链或法币为空时匹配全部
+type PricingRule struct {
+	MerchantId    uint64  `gorm:"column:merchant_id" json:"merchant_id"`       //  所属商户id，0为默认商户
+	Channel       string  `gorm:"column:channel" json:"channel"`               //  链类，为空匹配所有链
+	Currency      string  `gorm:"column:currency" json:"currency"`             //  法币，为空匹配所有法币
+	MarkupPercent float64 `gorm:"column:markup_percent" json:"markup_percent"` //  加价百分比，负数为折扣
+	FixedFee      float64 `gorm:"column:fixed_fee" json:"fixed_fee"`           //  固定手续费(usdt)
+	Rounding      string  `gorm:"column:rounding" json:"rounding"`             //  舍入方式 half_up up down half_even
+	MinAmount     float64 `gorm:"column:min_amount" json:"min_amount"`         //  最小订单金额(法币)，0为不限制
+	MaxAmount     float64 `gorm:"column:max_amount" json:"max_amount"`         //  最大订单金额(法币)，0为不限制
+	Status        int     `gorm:"column:status" json:"status"`                 //  1:启用 2:禁用
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (p *PricingRule) TableName() string {
+	return "pricing_rule"
+}
@@ -0,0 +1,15 @@
+package mdb
+
+// UsdtRateHistory 汇率历史，每次拉取汇率源聚合成功后记录
+type UsdtRateHistory struct {
+	Currency string  `gorm:"column:currency" json:"currency"` //  法币
+	Rate     float64 `gorm:"column:rate" json:"rate"`         //  聚合后的汇率，1 USDT 可兑换的法币数量
+	Source   string  `gorm:"column:source" json:"source"`     //  汇率来源
+	Quotes   string  `gorm:"column:quotes" json:"quotes"`     //  参与聚合的各汇率源报价(json)
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (u *UsdtRateHistory) TableName() string {
+	return "usdt_rate_history"
+}
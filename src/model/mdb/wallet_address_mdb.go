@@ -7,12 +7,34 @@ const (
 
 // WalletAddress  钱包表
 type WalletAddress struct {
-	Token   string `gorm:"column:token" json:"token"`     //  钱包token
-	Status  int64  `gorm:"column:status" json:"status"`   //  1:启用 2:禁用
-	Channel string `gorm:"column:channel" json:"channel"` //  链类
+	MerchantId       uint64  `gorm:"column:merchant_id" json:"merchant_id"`               //  所属商户id，0为默认商户
+	Token            string  `gorm:"column:token" json:"token"`                           //  钱包token
+	Status           int64   `gorm:"column:status" json:"status"`                         //  1:启用 2:禁用
+	Channel          string  `gorm:"column:channel" json:"channel"`                       //  链类
+	Weight           int     `gorm:"column:weight" json:"weight"`                         //  权重，按权重分配时使用
+	DailyVolumeLimit float64 `gorm:"column:daily_volume_limit" json:"daily_volume_limit"` //  每日收款金额上限，0为不限制
+	DailyOrderLimit  int     `gorm:"column:daily_order_limit" json:"daily_order_limit"`   //  每日订单数上限，0为不限制
+	Label            string  `gorm:"column:label" json:"label"`                           //  标签
+	Note             string  `gorm:"column:note" json:"note"`                             //  备注
+	GroupName        string  `gorm:"column:group_name" json:"group_name"`                 //  所属分组/负责人
+	CheckoutMessage  string  `gorm:"column:checkout_message" json:"checkout_message"`     //  收银台提示信息
 	BaseModel
 }
 
+// DisplayLabel 钱包展示名称，由分组与标签组成
+func (w *WalletAddress) DisplayLabel() string {
+	switch {
+	case w.GroupName != "" && w.Label != "":
+		return w.GroupName + "/" + w.Label
+	case w.Label != "":
+		return w.Label
+	case w.GroupName != "":
+		return w.GroupName
+	default:
+		return "-"
+	}
+}
+
 // TableName sets the insert table name for this struct type
 func (w *WalletAddress) TableName() string {
 	return "wallet_address"
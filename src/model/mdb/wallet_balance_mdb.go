@@ -0,0 +1,18 @@
+package mdb
+
+// WalletBalance 钱包余额快照
+type WalletBalance struct {
+	WalletId             uint64  `gorm:"column:wallet_id" json:"wallet_id"`                 //  钱包id
+	TokenWithChainPrefix string  `gorm:"column:token" json:"token"`                         //  钱包地址（带有链前缀）
+	Channel              string  `gorm:"column:channel" json:"channel"`                     //  链类
+	UsdtBalance          float64 `gorm:"column:usdt_balance" json:"usdt_balance"`           //  usdt余额
+	NativeBalance        float64 `gorm:"column:native_balance" json:"native_balance"`       //  原生代币(gas)余额
+	ReceivedAmount       float64 `gorm:"column:received_amount" json:"received_amount"`     //  对账窗口内链上收到的usdt
+	PaidOrderAmount      float64 `gorm:"column:paid_order_amount" json:"paid_order_amount"` //  对账窗口内对应支付成功订单的金额
+	BaseModel
+}
+
+// TableName sets the insert table name for this struct type
+func (w *WalletBalance) TableName() string {
+	return "wallet_balance"
+}
@@ -0,0 +1,42 @@
+package request
+
+import "github.com/gookit/validate"
+
+// ApiKeyListRequest api密钥列表
+type ApiKeyListRequest struct {
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"merchant_id"` // 所属商户，仅平台管理密钥可指定，其余密钥为请求密钥所属商户
+	BaseRequest
+}
+
+// ApiKeySaveRequest 新增或修改api密钥，id为0时新增并生成密钥
+type ApiKeySaveRequest struct {
+	Id         uint64  `json:"id"`
+	Name       string  `json:"name" validate:"maxLen:64"`
+	Scopes     string  `json:"scopes" validate:"required"` // 权限，逗号分隔 create query cancel admin
+	Status     int     `json:"status" validate:"in:0,1,2"` // 为0时新增启用，修改保持不变
+	ExpiredAt  *string `json:"expired_at"`                 // 过期时间 2006-01-02 15:04:05，空字符串为永不过期，修改时未传保持不变
+	Signature  string  `json:"signature"`
+	MerchantId uint64  `json:"merchant_id"` // 所属商户，仅平台管理密钥可指定，其余密钥为请求密钥所属商户
+}
+
+func (r ApiKeySaveRequest) Translates() map[string]string {
+	return validate.MS{
+		"Name":   "名称",
+		"Scopes": "权限",
+		"Status": "状态",
+	}
+}
+
+// ApiKeyDeleteRequest 删除api密钥
+type ApiKeyDeleteRequest struct {
+	Id         uint64 `json:"id" validate:"required"`
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"merchant_id"` // 所属商户，仅平台管理密钥可指定，其余密钥为请求密钥所属商户
+}
+
+func (r ApiKeyDeleteRequest) Translates() map[string]string {
+	return validate.MS{
+		"Id": "密钥id",
+	}
+}
@@ -1,5 +1,7 @@
 package request
 
+import "github.com/assimon/luuu/util/page"
+
 const (
 	OrderByFuncDesc = "DESC"
 	OrderByFuncAsc  = "OrderByFuncASC"
@@ -13,3 +15,18 @@ type BaseRequest struct {
 	OrderField string `json:"order_field"` // 排序字段
 	OrderFunc  string `json:"order_func"`  // 排序方法
 }
+
+// GetPageAndSize 获取合法的分页参数
+func (r BaseRequest) GetPageAndSize() (int, int) {
+	p, pageSize := r.Page, r.PageSize
+	if p <= 0 {
+		p = page.DefaultPage
+	}
+	if pageSize <= 0 {
+		pageSize = page.DefaultPageSize
+	}
+	if pageSize > page.MaxPageSize {
+		pageSize = page.MaxPageSize
+	}
+	return p, pageSize
+}
@@ -0,0 +1,32 @@
+package request
+
+import "github.com/gookit/validate"
+
+// CallbackLogRequest 回调投递记录
+type CallbackLogRequest struct {
+	TradeId    string `json:"trade_id"`   // epusdt订单号
+	OrderId    string `json:"order_id"`   // 客户交易id
+	Status     int    `json:"status"`     // 1：成功 2：失败，为空返回全部
+	StartTime  string `json:"start_time"` // 开始时间 2006-01-02 15:04:05
+	EndTime    string `json:"end_time"`   // 结束时间 2006-01-02 15:04:05
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+	BaseRequest
+}
+
+// CallbackResendRequest 手动重新回调，指定订单号时只回调该订单，否则按条件批量回调
+type CallbackResendRequest struct {
+	TradeId         string `json:"trade_id"`         // epusdt订单号
+	CallbackConfirm int    `json:"callback_confirm"` // 批量回调的回调状态 2：未确认 3：重试全部失败，为空两者都包含
+	StartTime       string `json:"start_time"`       // 开始时间 2006-01-02 15:04:05
+	EndTime         string `json:"end_time"`         // 结束时间 2006-01-02 15:04:05
+	Operator        string `json:"operator" validate:"required"`
+	Signature       string `json:"signature"`
+	MerchantId      uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r CallbackResendRequest) Translates() map[string]string {
+	return validate.MS{
+		"Operator": "操作人",
+	}
+}
@@ -0,0 +1,8 @@
+package request
+
+// ChannelListRequest 支持的链列表
+type ChannelListRequest struct {
+	Currency   string `json:"currency"` // 法币，为空则为默认法币
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+}
@@ -0,0 +1,68 @@
+package request
+
+import "github.com/gookit/validate"
+
+// FeeRuleListRequest 手续费规则列表
+type FeeRuleListRequest struct {
+	MerchantId uint64 `json:"merchant_id"`
+	Channel    string `json:"channel"`
+	Signature  string `json:"signature"`
+	BaseRequest
+}
+
+// FeeRuleSaveRequest 新增或修改手续费规则，id为0时新增
+type FeeRuleSaveRequest struct {
+	Id         uint64  `json:"id"`
+	MerchantId uint64  `json:"merchant_id"`
+	Channel    string  `json:"channel"` // 为空匹配所有链
+	Percent    float64 `json:"percent" validate:"min:0|max:100"`
+	FixedFee   float64 `json:"fixed_fee" validate:"min:0"`
+	Status     int     `json:"status" validate:"in:0,1,2"`
+	Signature  string  `json:"signature"`
+}
+
+func (r FeeRuleSaveRequest) Translates() map[string]string {
+	return validate.MS{
+		"Percent":  "手续费百分比",
+		"FixedFee": "固定手续费",
+		"Status":   "状态",
+	}
+}
+
+// FeeRuleDeleteRequest 删除手续费规则
+type FeeRuleDeleteRequest struct {
+	Id        uint64 `json:"id" validate:"required"`
+	Signature string `json:"signature"`
+}
+
+func (r FeeRuleDeleteRequest) Translates() map[string]string {
+	return validate.MS{
+		"Id": "规则id",
+	}
+}
+
+// FeeLedgerRequest 手续费台账
+type FeeLedgerRequest struct {
+	MerchantId uint64 `json:"merchant_id"` // 所属商户，仅平台管理密钥可指定，其余密钥为请求密钥所属商户
+	Channel    string `json:"channel"`
+	StartTime  string `json:"start_time"` // 开始时间 2006-01-02 15:04:05
+	EndTime    string `json:"end_time"`   // 结束时间 2006-01-02 15:04:05
+	Signature  string `json:"signature"`
+	BaseRequest
+}
+
+// FeeStatementRequest 手续费对账单
+type FeeStatementRequest struct {
+	MerchantId uint64 `json:"merchant_id"` // 所属商户，仅平台管理密钥可指定，其余密钥为请求密钥所属商户
+	Channel    string `json:"channel"`
+	StartTime  string `json:"start_time" validate:"required"` // 开始时间 2006-01-02 15:04:05
+	EndTime    string `json:"end_time" validate:"required"`   // 结束时间 2006-01-02 15:04:05
+	Signature  string `json:"signature"`
+}
+
+func (r FeeStatementRequest) Translates() map[string]string {
+	return validate.MS{
+		"StartTime": "开始时间",
+		"EndTime":   "结束时间",
+	}
+}
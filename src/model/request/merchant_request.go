@@ -0,0 +1,29 @@
+package request
+
+import "github.com/gookit/validate"
+
+// MerchantListRequest 商户列表
+type MerchantListRequest struct {
+	Signature string `json:"signature"`
+	BaseRequest
+}
+
+// MerchantSaveRequest 新增或修改商户，id为0时新增
+type MerchantSaveRequest struct {
+	Id           uint64 `json:"id"`
+	Name         string `json:"name" validate:"required|maxLen:64"`
+	Status       int    `json:"status" validate:"in:0,1,2"`
+	NotifyUrl    string `json:"notify_url" validate:"maxLen:255"`
+	NotifyEvents string `json:"notify_events"` // 默认额外开启回调的事件，逗号分隔，为空使用全局配置
+	TgChatIds    string `json:"tg_chat_ids" validate:"maxLen:255"`
+	Signature    string `json:"signature"`
+}
+
+func (r MerchantSaveRequest) Translates() map[string]string {
+	return validate.MS{
+		"Name":      "商户名称",
+		"Status":    "状态",
+		"NotifyUrl": "默认异步回调网址",
+		"TgChatIds": "telegram会话id",
+	}
+}
@@ -1,24 +1,37 @@
 package request
 
-import "github.com/gookit/validate"
+import (
+	"encoding/json"
+
+	"github.com/gookit/validate"
+)
 
 // CreateTransactionRequest 创建交易请求
 type CreateTransactionRequest struct {
-	OrderId      string  `json:"order_id" validate:"required|maxLen:32"`
-	Amount       float64 `json:"amount" validate:"required|isFloat|gt:0.01"`
-	NotifyUrl    string  `json:"notify_url" validate:"required"`
-	Signature    string  `json:"signature"  validate:"required"`
-	ExchangeRate string  `json:"exchange_rate"`
-	Channel      string  `json:"channel"`
-	RedirectUrl  string  `json:"redirect_url"`
+	OrderId       string          `json:"order_id" validate:"required|maxLen:32"`
+	Amount        float64         `json:"amount" validate:"required|isFloat|gt:0.01"`
+	NotifyUrl     string          `json:"notify_url"` // 为空使用商户的默认回调地址
+	Signature     string          `json:"signature"`
+	ExchangeRate  string          `json:"exchange_rate"`
+	Currency      string          `json:"currency" validate:"maxLen:10"`
+	QuoteId       string          `json:"quote_id" validate:"maxLen:64"`
+	Channel       string          `json:"channel"`
+	RedirectUrl   string          `json:"redirect_url"`
+	NotifyEvents  string          `json:"notify_events"` // 额外开启回调的事件，逗号分隔，为空使用商户默认或全局配置
+	CustomerEmail string          `json:"customer_email" validate:"email|maxLen:128"`
+	CustomerName  string          `json:"customer_name" validate:"maxLen:64"`
+	Metadata      json.RawMessage `json:"metadata"` // 商户自定义数据，须为json对象
+	ApiKeyId      string          `json:"-"`        // 签名使用的密钥id，由中间件设置
+	MerchantId    uint64          `json:"-"`        // 请求密钥所属商户，由中间件设置
 }
 
 func (r CreateTransactionRequest) Translates() map[string]string {
 	return validate.MS{
-		"OrderId":   "订单号",
-		"Amount":    "支付金额",
-		"NotifyUrl": "异步回调网址",
-		"Signature": "签名",
+		"OrderId":       "订单号",
+		"Amount":        "支付金额",
+		"NotifyUrl":     "异步回调网址",
+		"CustomerEmail": "客户邮箱",
+		"CustomerName":  "客户名称",
 	}
 }
 
@@ -29,3 +42,72 @@ type OrderProcessingRequest struct {
 	TradeId              string
 	BlockTransactionId   string
 }
+
+// QuoteRequest 报价请求
+type QuoteRequest struct {
+	Amount     float64 `json:"amount" validate:"required|isFloat|gt:0.01"`
+	Currency   string  `json:"currency" validate:"maxLen:10"`
+	Channel    string  `json:"channel"` // 为空则返回所有链
+	Signature  string  `json:"signature"`
+	MerchantId uint64  `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r QuoteRequest) Translates() map[string]string {
+	return validate.MS{
+		"Amount": "支付金额",
+	}
+}
+
+// SelectChannelRequest 收银台选择付款网络
+type SelectChannelRequest struct {
+	Channel string `json:"channel" form:"channel" validate:"required"`
+}
+
+func (r SelectChannelRequest) Translates() map[string]string {
+	return validate.MS{
+		"Channel": "付款网络",
+	}
+}
+
+// SwitchChannelRequest 切换订单付款网络
+type SwitchChannelRequest struct {
+	TradeId    string `json:"trade_id" validate:"required"`
+	Channel    string `json:"channel" validate:"required"`
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r SwitchChannelRequest) Translates() map[string]string {
+	return validate.MS{
+		"TradeId": "epusdt订单号",
+		"Channel": "付款网络",
+	}
+}
+
+// CancelOrderRequest 取消订单
+type CancelOrderRequest struct {
+	TradeId    string `json:"trade_id" validate:"required"`
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r CancelOrderRequest) Translates() map[string]string {
+	return validate.MS{
+		"TradeId": "epusdt订单号",
+	}
+}
+
+// OrderQueryRequest 查询订单
+type OrderQueryRequest struct {
+	TradeId    string `json:"trade_id" validate:"requiredWithout:OrderId"`
+	OrderId    string `json:"order_id"`
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r OrderQueryRequest) Translates() map[string]string {
+	return validate.MS{
+		"TradeId": "epusdt订单号",
+		"OrderId": "客户交易id",
+	}
+}
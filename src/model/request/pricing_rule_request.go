@@ -0,0 +1,51 @@
+package request
+
+import "github.com/gookit/validate"
+
+// PricingRuleListRequest 定价规则列表
+type PricingRuleListRequest struct {
+	Channel    string `json:"channel"`
+	Currency   string `json:"currency"`
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+	BaseRequest
+}
+
+// PricingRuleSaveRequest 新增或修改定价规则，id为0时新增
+type PricingRuleSaveRequest struct {
+	Id            uint64  `json:"id"`
+	Channel       string  `json:"channel"`
+	Currency      string  `json:"currency" validate:"maxLen:10"`
+	MarkupPercent float64 `json:"markup_percent" validate:"min:-100"`
+	FixedFee      float64 `json:"fixed_fee"`
+	Rounding      string  `json:"rounding" validate:"in:half_up,up,down,half_even"`
+	MinAmount     float64 `json:"min_amount" validate:"min:0"`
+	MaxAmount     float64 `json:"max_amount" validate:"min:0"`
+	Status        int     `json:"status" validate:"in:0,1,2"`
+	Signature     string  `json:"signature"`
+	MerchantId    uint64  `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r PricingRuleSaveRequest) Translates() map[string]string {
+	return validate.MS{
+		"Currency":      "法币",
+		"MarkupPercent": "加价百分比",
+		"Rounding":      "舍入方式",
+		"MinAmount":     "最小订单金额",
+		"MaxAmount":     "最大订单金额",
+		"Status":        "状态",
+	}
+}
+
+// PricingRuleDeleteRequest 删除定价规则
+type PricingRuleDeleteRequest struct {
+	Id         uint64 `json:"id" validate:"required"`
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r PricingRuleDeleteRequest) Translates() map[string]string {
+	return validate.MS{
+		"Id": "规则id",
+	}
+}
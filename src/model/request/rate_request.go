@@ -0,0 +1,16 @@
+package request
+
+// RateCurrentRequest 当前汇率
+type RateCurrentRequest struct {
+	Currency  string `json:"currency"` // 法币，为空返回所有已配置法币
+	Signature string `json:"signature"`
+}
+
+// RateHistoryRequest 汇率历史
+type RateHistoryRequest struct {
+	Currency  string `json:"currency"`
+	StartTime string `json:"start_time"` // 开始时间 2006-01-02 15:04:05
+	EndTime   string `json:"end_time"`   // 结束时间 2006-01-02 15:04:05
+	Signature string `json:"signature"`
+	BaseRequest
+}
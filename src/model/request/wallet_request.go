@@ -0,0 +1,68 @@
+package request
+
+import "github.com/gookit/validate"
+
+// WalletBalanceHistoryRequest 钱包余额历史
+type WalletBalanceHistoryRequest struct {
+	WalletId   uint64 `json:"wallet_id" validate:"required"`
+	StartTime  string `json:"start_time"` // 开始时间 2006-01-02 15:04:05
+	EndTime    string `json:"end_time"`   // 结束时间 2006-01-02 15:04:05
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+	BaseRequest
+}
+
+func (r WalletBalanceHistoryRequest) Translates() map[string]string {
+	return validate.MS{
+		"WalletId": "钱包id",
+	}
+}
+
+// WalletListRequest 钱包列表
+type WalletListRequest struct {
+	Channel    string `json:"channel"`
+	Status     int    `json:"status"`     // 1:启用 2:禁用
+	Label      string `json:"label"`      // 标签，模糊匹配
+	GroupName  string `json:"group_name"` // 分组
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+	BaseRequest
+}
+
+// WalletUpdateRequest 修改钱包信息，未传的字段保持不变
+type WalletUpdateRequest struct {
+	Id               uint64   `json:"id" validate:"required"`
+	Label            *string  `json:"label"`
+	Note             *string  `json:"note"`
+	GroupName        *string  `json:"group_name"`
+	CheckoutMessage  *string  `json:"checkout_message"`
+	Weight           *int     `json:"weight" validate:"min:0"`
+	DailyVolumeLimit *float64 `json:"daily_volume_limit" validate:"min:0"`
+	DailyOrderLimit  *int     `json:"daily_order_limit" validate:"min:0"`
+	Signature        string   `json:"signature"`
+	MerchantId       uint64   `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r WalletUpdateRequest) Translates() map[string]string {
+	return validate.MS{
+		"Id":               "钱包id",
+		"Weight":           "权重",
+		"DailyVolumeLimit": "每日收款金额上限",
+		"DailyOrderLimit":  "每日订单数上限",
+	}
+}
+
+// WalletAddRequest 添加钱包
+type WalletAddRequest struct {
+	Channel    string `json:"channel" validate:"required"`
+	Token      string `json:"token" validate:"required"`
+	Signature  string `json:"signature"`
+	MerchantId uint64 `json:"-"` // 请求密钥所属商户，由中间件设置
+}
+
+func (r WalletAddRequest) Translates() map[string]string {
+	return validate.MS{
+		"Channel": "所属链",
+		"Token":   "钱包地址",
+	}
+}
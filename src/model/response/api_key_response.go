@@ -0,0 +1,9 @@
+package response
+
+import "github.com/assimon/luuu/model/mdb"
+
+// ApiKeyResponse api密钥，签名密钥仅在新增时返回
+type ApiKeyResponse struct {
+	*mdb.ApiKey
+	Secret string `json:"secret,omitempty"`
+}
@@ -0,0 +1,7 @@
+package response
+
+// CallbackResendResponse 手动重新回调结果
+type CallbackResendResponse struct {
+	Count    int      `json:"count"`     // 已重新投递的订单数量
+	TradeIds []string `json:"trade_ids"` // 已重新投递的订单号
+}
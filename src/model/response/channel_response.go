@@ -0,0 +1,16 @@
+package response
+
+// ChannelResponse 链信息
+type ChannelResponse struct {
+	Channel         string   `json:"channel"`          // 链编码，创建订单时传入
+	DisplayName     string   `json:"display_name"`     // 展示名称
+	Assets          []string `json:"assets"`           // 支持收款的币种
+	Currency        string   `json:"currency"`         // 法币
+	MinAmount       float64  `json:"min_amount"`       // 最小订单金额(法币)
+	MaxAmount       float64  `json:"max_amount"`       // 最大订单金额(法币)，0为不限制
+	AmountPrecision int32    `json:"amount_precision"` // 应付金额小数位数
+	Rate            float64  `json:"rate"`             // 当前汇率，汇率过期时为0
+	RateStale       bool     `json:"rate_stale"`       // 汇率是否已过期
+	ConfirmSeconds  int      `json:"confirm_seconds"`  // 预计到账确认时间(秒)
+	Available       bool     `json:"available"`        // 是否有启用的钱包
+}
@@ -0,0 +1,19 @@
+package response
+
+// FeeStatementResponse 手续费对账单
+type FeeStatementResponse struct {
+	MerchantId uint64             `json:"merchant_id"` //  商户id
+	StartTime  string             `json:"start_time"`  //  开始时间
+	EndTime    string             `json:"end_time"`    //  结束时间
+	Total      FeeStatementItem   `json:"total"`       //  合计
+	Channels   []FeeStatementItem `json:"channels"`    //  按链汇总
+}
+
+// FeeStatementItem 手续费汇总
+type FeeStatementItem struct {
+	Channel     string  `json:"channel,omitempty"` //  链，合计时为空
+	OrderCount  int64   `json:"order_count"`       //  订单数
+	GrossAmount float64 `json:"gross_amount"`      //  实收金额(usdt)
+	FeeAmount   float64 `json:"fee_amount"`        //  手续费(usdt)
+	NetAmount   float64 `json:"net_amount"`        //  净额(usdt)
+}
@@ -1,5 +1,7 @@
 package response
 
+import "encoding/json"
+
 // CreateTransactionResponse 创建订单成功返回
 type CreateTransactionResponse struct {
 	TradeId        string  `json:"trade_id"`        //  epusdt订单号
@@ -13,12 +15,44 @@ type CreateTransactionResponse struct {
 
 // OrderNotifyResponse 订单异步回调结构体
 type OrderNotifyResponse struct {
-	TradeId            string  `json:"trade_id"`             //  epusdt订单号
-	OrderId            string  `json:"order_id"`             //  客户交易id
-	Amount             float64 `json:"amount"`               //  订单金额，保留4位小数
-	ActualAmount       float64 `json:"actual_amount"`        //  订单实际需要支付的金额，保留4位小数
-	Token              string  `json:"token"`                //  收款钱包地址(带有链前缀)
-	BlockTransactionId string  `json:"block_transaction_id"` // 区块id
-	Signature          string  `json:"signature"`            // 签名
-	Status             int     `json:"status"`               //  1：等待支付，2：支付成功，3：已过期
+	TradeId            string          `json:"trade_id"`                 //  epusdt订单号
+	OrderId            string          `json:"order_id"`                 //  客户交易id
+	Amount             float64         `json:"amount"`                   //  订单金额，保留4位小数
+	ActualAmount       float64         `json:"actual_amount"`            //  订单实际需要支付的金额，保留4位小数
+	Token              string          `json:"token"`                    //  收款钱包地址(带有链前缀)
+	BlockTransactionId string          `json:"block_transaction_id"`     // 区块id
+	Signature          string          `json:"signature"`                // 签名
+	Status             int             `json:"status"`                   //  1：等待支付，2：支付成功，3：已过期，4：已取消
+	EventType          string          `json:"event_type"`               //  事件类型 order.paid order.expired order.cancelled order.confirming
+	EventId            string          `json:"event_id"`                 //  事件id，同一订单的同一事件不变
+	FeeAmount          *float64        `json:"fee_amount,omitempty"`     //  平台手续费(usdt)，仅支付成功事件返回
+	NetAmount          *float64        `json:"net_amount,omitempty"`     //  扣除手续费后的净额(usdt)，仅支付成功事件返回
+	CustomerEmail      string          `json:"customer_email,omitempty"` //  客户邮箱
+	CustomerName       string          `json:"customer_name,omitempty"`  //  客户名称
+	Metadata           json.RawMessage `json:"metadata,omitempty"`       //  商户自定义数据
+}
+
+// CancelOrderResponse 取消订单返回
+type CancelOrderResponse struct {
+	TradeId string `json:"trade_id"` //  epusdt订单号
+	OrderId string `json:"order_id"` //  客户交易id
+	Status  int    `json:"status"`   //  订单状态 4：已取消
+}
+
+// OrderInfoResponse 订单查询返回
+type OrderInfoResponse struct {
+	TradeId            string          `json:"trade_id"`                 //  epusdt订单号
+	OrderId            string          `json:"order_id"`                 //  客户交易id
+	Amount             float64         `json:"amount"`                   //  订单金额，保留4位小数
+	Currency           string          `json:"currency"`                 //  订单金额的法币
+	ActualAmount       float64         `json:"actual_amount"`            //  订单实际需要支付的金额，保留4位小数
+	Token              string          `json:"token"`                    //  收款钱包地址(带有链前缀)
+	BlockTransactionId string          `json:"block_transaction_id"`     // 区块id
+	Status             int             `json:"status"`                   //  1：等待支付，2：支付成功，3：已过期，4：已取消
+	CallbackConfirm    int             `json:"callback_confirm"`         // 回调是否已确认 1是 2否 3重试全部失败
+	ExpirationTime     int64           `json:"expiration_time"`          // 过期时间 时间戳
+	CreatedAt          int64           `json:"created_at"`               // 创建时间 时间戳
+	CustomerEmail      string          `json:"customer_email,omitempty"` //  客户邮箱
+	CustomerName       string          `json:"customer_name,omitempty"`  //  客户名称
+	Metadata           json.RawMessage `json:"metadata,omitempty"`       //  商户自定义数据
 }
@@ -1,12 +1,26 @@
 package response
 
 type CheckoutCounterResponse struct {
-	TradeId        string  `json:"trade_id"`        //  epusdt订单号
-	ActualAmount   float64 `json:"actual_amount"`   //  订单实际需要支付的金额，保留4位小数
-	Channel        string  `json:"channel"`         //  收款钱包网络
-	Token          string  `json:"token"`           //  收款钱包地址
-	ExpirationTime int64   `json:"expiration_time"` // 过期时间 时间戳
-	RedirectUrl    string  `json:"redirect_url"`
+	TradeId         string            `json:"trade_id"`        //  epusdt订单号
+	ActualAmount    float64           `json:"actual_amount"`   //  订单实际需要支付的金额，保留4位小数
+	Channel         string            `json:"channel"`         //  收款钱包网络
+	Token           string            `json:"token"`           //  收款钱包地址
+	ExpirationTime  int64             `json:"expiration_time"` // 过期时间 时间戳
+	RedirectUrl     string            `json:"redirect_url"`
+	CheckoutMessage string            `json:"checkout_message"` // 收款钱包的收银台提示信息
+	SelectChannel   bool              `json:"select_channel"`   // 是否需要客户选择付款网络
+	Amount          float64           `json:"amount"`           // 订单金额(法币)
+	Currency        string            `json:"currency"`         // 法币
+	Channels        []CheckoutChannel `json:"channels"`         // 可选择的付款网络
+}
+
+// CheckoutChannel 收银台可选择的付款网络
+type CheckoutChannel struct {
+	Channel      string  `json:"channel"`       // 链编码
+	DisplayName  string  `json:"display_name"`  // 展示名称
+	Asset        string  `json:"asset"`         // 收款币种
+	ActualAmount float64 `json:"actual_amount"` // 预计需要支付的金额
+	Available    bool    `json:"available"`     // 是否可选择
 }
 
 type CheckStatusResponse struct {
@@ -0,0 +1,28 @@
+package response
+
+import "github.com/golang-module/carbon/v2"
+
+// QuoteResponse 报价
+type QuoteResponse struct {
+	QuoteId        string          `json:"quote_id"`        // 报价id，有效期内创建订单时传入可按报价金额下单
+	MerchantId     uint64          `json:"merchant_id"`     // 报价所属商户，仅该商户可按报价下单
+	Amount         float64         `json:"amount"`          // 法币金额
+	Currency       string          `json:"currency"`        // 法币
+	Rate           float64         `json:"rate"`            // 汇率
+	RateSource     string          `json:"rate_source"`     // 汇率来源
+	RateAt         carbon.DateTime `json:"rate_at"`         // 汇率更新时间
+	ExpirationTime int64           `json:"expiration_time"` // 报价过期时间 时间戳
+	Channels       []QuoteChannel  `json:"channels"`
+}
+
+// QuoteChannel 各链报价
+type QuoteChannel struct {
+	Channel       string  `json:"channel"`         // 链
+	Asset         string  `json:"asset"`           // 收款币种
+	ActualAmount  float64 `json:"actual_amount"`   // 需要支付的金额，下单时可能按金额区分规则略有偏移
+	Available     bool    `json:"available"`       // 当前是否有可用钱包
+	PricingRuleId uint64  `json:"pricing_rule_id"` // 应用的定价规则id，0为未应用
+	MarkupPercent float64 `json:"markup_percent"`  // 应用的加价百分比
+	FixedFee      float64 `json:"fixed_fee"`       // 应用的固定手续费(usdt)
+	Rounding      string  `json:"rounding"`        // 应用的舍入方式
+}
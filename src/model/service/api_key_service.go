@@ -0,0 +1,143 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/page"
+	"github.com/golang-module/carbon/v2"
+)
+
+const (
+	ApiKeyIdPrefix    = "ak_"
+	ApiKeyIdBytes     = 8  // 密钥id随机部分字节数
+	ApiKeySecretBytes = 24 // 签名密钥字节数
+)
+
+// GetApiKeyList 分页获取api密钥
+func GetApiKeyList(req *request.ApiKeyListRequest) ([]mdb.ApiKey, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	apiKeys, total, err := data.GetApiKeyList(req.MerchantId, p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return apiKeys, page.GetPagination(p, pageSize, total), nil
+}
+
+// SaveApiKey 新增或修改api密钥，新增时生成密钥id与签名密钥，签名密钥不可修改
+// 轮换密钥时先新增密钥并切换调用方，再为旧密钥设置过期时间或停用
+func SaveApiKey(req *request.ApiKeySaveRequest) (*response.ApiKeyResponse, error) {
+	scopes, err := NormalizeApiKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkMerchantUsable(req.MerchantId); err != nil {
+		return nil, err
+	}
+	var expiredAt carbon.Time
+	if req.ExpiredAt != nil && *req.ExpiredAt != "" {
+		expiredAt = carbon.Time{Carbon: carbon.Parse(*req.ExpiredAt)}
+		if expiredAt.Error != nil {
+			return nil, constant.ParamsMarshalErr
+		}
+	}
+	apiKey := new(mdb.ApiKey)
+	resp := &response.ApiKeyResponse{ApiKey: apiKey}
+	if req.Id > 0 {
+		apiKey, err = data.GetApiKeyById(req.Id)
+		if err != nil {
+			return nil, err
+		}
+		if apiKey.ID <= 0 || apiKey.MerchantId != req.MerchantId {
+			return nil, constant.ApiKeyNotExists
+		}
+		resp.ApiKey = apiKey
+	} else {
+		apiKey.MerchantId = req.MerchantId
+		if apiKey.KeyId, err = randomHex(ApiKeyIdBytes); err != nil {
+			return nil, err
+		}
+		apiKey.KeyId = ApiKeyIdPrefix + apiKey.KeyId
+		if apiKey.Secret, err = randomHex(ApiKeySecretBytes); err != nil {
+			return nil, err
+		}
+		resp.Secret = apiKey.Secret
+	}
+	apiKey.Name = req.Name
+	apiKey.Scopes = scopes
+	// 修改时未传的过期时间与状态保持不变，避免改名等操作清除计划中的过期时间
+	if req.ExpiredAt != nil {
+		apiKey.ExpiredAt = expiredAt
+	}
+	if req.Status > 0 {
+		apiKey.Status = req.Status
+	}
+	if apiKey.Status == 0 {
+		apiKey.Status = mdb.ApiKeyStatusEnable
+	}
+	if err = data.SaveApiKey(apiKey); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteApiKey 删除api密钥，该密钥所建订单的回调改用 api_auth_token 签名
+func DeleteApiKey(req *request.ApiKeyDeleteRequest) error {
+	apiKey, err := data.GetApiKeyById(req.Id)
+	if err != nil {
+		return err
+	}
+	if apiKey.ID <= 0 || apiKey.MerchantId != req.MerchantId {
+		return constant.ApiKeyNotExists
+	}
+	return data.DeleteApiKeyById(apiKey.ID)
+}
+
+// NormalizeApiKeyScopes 校验并去重权限
+func NormalizeApiKeyScopes(scopes string) (string, error) {
+	var normalized []string
+	for _, scope := range strings.Split(scopes, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+		supported := false
+		for _, item := range mdb.ApiKeyScopes {
+			if item == scope {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return "", constant.ApiKeyScopeNotSupportErr
+		}
+		exists := false
+		for _, item := range normalized {
+			if item == scope {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return "", constant.ApiKeyScopeNotSupportErr
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
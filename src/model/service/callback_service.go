@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/mq"
+	"github.com/assimon/luuu/mq/handle"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/log"
+	"github.com/assimon/luuu/util/page"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	ResendCallbackMaxBatch = 500 // 批量重新回调的最大订单数
+	OrderEventMaxRetry     = 3   // 非最终事件回调最多重试次数
+)
+
+// GetCallbackLogList 分页获取回调投递记录
+func GetCallbackLogList(req *request.CallbackLogRequest) ([]mdb.CallbackLog, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	callbackLogs, total, err := data.GetCallbackLogList(req.MerchantId, req.TradeId, req.OrderId, req.Status, req.StartTime, req.EndTime, p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return callbackLogs, page.GetPagination(p, pageSize, total), nil
+}
+
+// EnqueueOrderCallback 投递订单回调任务，剩余重试次数按订单已回调次数计算
+// 订单已有回调任务在队列中时不重复投递
+func EnqueueOrderCallback(order *mdb.Orders) error {
+	remaining := config.GetCallbackMaxAttempts() - order.CallbackNum
+	if remaining <= 0 {
+		return nil
+	}
+	orderCallbackQueue, err := handle.NewOrderCallbackQueue(order)
+	if err != nil {
+		return err
+	}
+	opts := []asynq.Option{asynq.TaskID(handle.OrderCallbackTaskId(order.TradeId)), asynq.MaxRetry(remaining - 1)}
+	_, err = mq.MClient.Enqueue(orderCallbackQueue, opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		// 已归档的任务不会再执行，删除后重新投递
+		if !removeOrderCallbackTask(order.TradeId, asynq.TaskStateArchived) {
+			return nil
+		}
+		_, err = mq.MClient.Enqueue(orderCallbackQueue, opts...)
+	}
+	return err
+}
+
+// removeOrderCallbackTask 删除处于指定状态的订单回调任务，返回是否已删除
+func removeOrderCallbackTask(tradeId string, states ...asynq.TaskState) bool {
+	taskId := handle.OrderCallbackTaskId(tradeId)
+	info, err := mq.MInspector.GetTaskInfo("default", taskId)
+	if err != nil {
+		return false
+	}
+	for _, state := range states {
+		if info.State == state {
+			return mq.MInspector.DeleteTask("default", taskId) == nil
+		}
+	}
+	return false
+}
+
+// EnqueueOrderEvent 投递订单事件回调，订单未开启该事件时忽略
+// 最终事件按订单回调次数与状态投递，等待区块确认事件在订单有效期内只投递一次
+func EnqueueOrderEvent(order *mdb.Orders, event string) error {
+	if !order.IsNotifyEventEnabled(event) {
+		return nil
+	}
+	if event != mdb.OrderEventConfirming {
+		return EnqueueOrderCallback(order)
+	}
+	ok, err := data.AcquireOrderEvent(order.TradeId, event, config.GetOrderExpirationTimeDuration())
+	if err != nil || !ok {
+		return err
+	}
+	orderEventQueue, err := handle.NewOrderEventQueue(order, event)
+	if err != nil {
+		return err
+	}
+	_, err = mq.MClient.Enqueue(orderEventQueue, asynq.TaskID(handle.OrderEventTaskId(order.TradeId, event)), asynq.MaxRetry(OrderEventMaxRetry))
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+	return err
+}
+
+// NormalizeNotifyEvents 校验额外开启回调的事件，事件可省略 order. 前缀，none 表示不开启
+func NormalizeNotifyEvents(events []string) (string, error) {
+	var normalized []string
+	for _, event := range events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if event == "" || event == "none" {
+			continue
+		}
+		if !strings.HasPrefix(event, "order.") {
+			event = "order." + event
+		}
+		supported := false
+		for _, item := range mdb.OptionalOrderEvents {
+			if item == event {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return "", constant.NotifyEventNotSupportErr
+		}
+		normalized = append(normalized, event)
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// RedriveOrderCallbacks 重新投递未完成回调的订单，避免入队失败或队列丢失导致回调遗漏
+func RedriveOrderCallbacks() {
+	orders, err := data.GetPendingCallbackOrders(config.GetCallbackMaxAttempts())
+	if err != nil {
+		log.Sugar.Error("[callback] ", err.Error())
+		return
+	}
+	for i := range orders {
+		if err = EnqueueOrderCallback(&orders[i]); err != nil {
+			log.Sugar.Errorf("[callback] redrive trade_id:%s err:%s", orders[i].TradeId, err.Error())
+		}
+	}
+}
+
+// ResendOrderCallback 手动重新回调订单的最终事件
+func ResendOrderCallback(tradeId, operator, source string) error {
+	order, err := GetOrderInfoByTradeId(tradeId)
+	if err != nil {
+		return err
+	}
+	if order.CallbackEvent() == "" {
+		return constant.OrderNotPaidErr
+	}
+	return resendOrderCallback(order, operator, source)
+}
+
+// ResendOrderCallbackByApi 管理接口重新回调，未指定订单号时按条件批量回调
+func ResendOrderCallbackByApi(req *request.CallbackResendRequest) (*response.CallbackResendResponse, error) {
+	resp := &response.CallbackResendResponse{TradeIds: []string{}}
+	if req.TradeId != "" {
+		order, err := GetMerchantOrderInfoByTradeId(req.MerchantId, req.TradeId)
+		if err != nil {
+			return nil, err
+		}
+		if order.CallbackEvent() == "" {
+			return nil, constant.OrderNotPaidErr
+		}
+		if err = resendOrderCallback(order, req.Operator, mdb.CallbackResendSourceApi); err != nil {
+			return nil, err
+		}
+		resp.Count, resp.TradeIds = 1, []string{req.TradeId}
+		return resp, nil
+	}
+	if strings.TrimSpace(req.StartTime) == "" {
+		return nil, constant.CallbackResendParamsErr
+	}
+	callbackConfirms := []int{mdb.CallBackConfirmNo, mdb.CallBackConfirmDead}
+	if req.CallbackConfirm > 0 {
+		callbackConfirms = []int{req.CallbackConfirm}
+	}
+	orders, err := data.GetResendCallbackOrders(req.MerchantId, callbackConfirms, req.StartTime, req.EndTime, ResendCallbackMaxBatch)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		if err = resendOrderCallback(&orders[i], req.Operator, mdb.CallbackResendSourceApi); err != nil {
+			log.Sugar.Errorf("[callback] resend trade_id:%s err:%s", orders[i].TradeId, err.Error())
+			continue
+		}
+		resp.Count++
+		resp.TradeIds = append(resp.TradeIds, orders[i].TradeId)
+	}
+	return resp, nil
+}
+
+// resendOrderCallback 重置订单回调次数与状态并立即投递回调任务，同时记录操作人
+func resendOrderCallback(order *mdb.Orders, operator, source string) error {
+	// 删除排队等待重试的任务，使回调立即发送
+	removeOrderCallbackTask(order.TradeId, asynq.TaskStatePending, asynq.TaskStateScheduled, asynq.TaskStateRetry, asynq.TaskStateArchived)
+	err := data.ResetOrderCallbackById(order.ID)
+	if err != nil {
+		return err
+	}
+	err = data.CreateCallbackResendLog(&mdb.CallbackResendLog{
+		TradeId:             order.TradeId,
+		Operator:            operator,
+		Source:              source,
+		PrevCallbackNum:     order.CallbackNum,
+		PrevCallbackConfirm: order.CallBackConfirm,
+	})
+	if err != nil {
+		log.Sugar.Error(err)
+	}
+	order.CallbackNum = 0
+	order.CallBackConfirm = mdb.CallBackConfirmNo
+	return EnqueueOrderCallback(order)
+}
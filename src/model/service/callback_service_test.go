@@ -0,0 +1,36 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/assimon/luuu/util/constant"
+)
+
+func TestNormalizeNotifyEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   string
+		err    error
+	}{
+		{"empty", nil, "", nil},
+		{"none", []string{"none"}, "", nil},
+		{"short names", []string{"expired", "cancelled"}, "order.expired,order.cancelled", nil},
+		{"full names", []string{"order.confirming"}, "order.confirming", nil},
+		{"case and spaces", []string{" Expired ", "", "ORDER.CANCELLED"}, "order.expired,order.cancelled", nil},
+		{"final event not optional", []string{"paid"}, "", constant.NotifyEventNotSupportErr},
+		{"unknown", []string{"expired", "refunded"}, "", constant.NotifyEventNotSupportErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeNotifyEvents(tt.events)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("NormalizeNotifyEvents(%v) err = %v, want %v", tt.events, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeNotifyEvents(%v) = %q, want %q", tt.events, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+)
+
+// GetChannelList 获取支持的链及其金额范围、汇率、钱包可用情况
+func GetChannelList(req *request.ChannelListRequest) ([]response.ChannelResponse, error) {
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = config.GetUsdtRateDefaultCurrency()
+	}
+	usdtRate, err := getUsdtRate(currency)
+	if err != nil {
+		return nil, err
+	}
+	walletCount, err := data.CountAvailableWalletByChannel(req.MerchantId)
+	if err != nil {
+		return nil, err
+	}
+	var channels []response.ChannelResponse
+	for _, chain := range model.Chains {
+		pricingRule, err := MatchPricingRule(req.MerchantId, chain.Code, currency)
+		if err != nil {
+			return nil, err
+		}
+		channel := response.ChannelResponse{
+			Channel:         chain.Code,
+			DisplayName:     chain.DisplayName,
+			Assets:          chain.Assets,
+			Currency:        currency,
+			MinAmount:       CnyMinimumPaymentAmount,
+			AmountPrecision: config.GetAmountRule(chain.Code).Precision,
+			RateStale:       usdtRate.Stale,
+			ConfirmSeconds:  chain.ConfirmSeconds,
+			Available:       walletCount[chain.Code] > 0,
+		}
+		if !usdtRate.Stale {
+			channel.Rate = usdtRate.Rate
+		}
+		if pricingRule != nil {
+			if pricingRule.MinAmount > channel.MinAmount {
+				channel.MinAmount = pricingRule.MinAmount
+			}
+			channel.MaxAmount = pricingRule.MaxAmount
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
+)
+
+// MatchFeeRule 匹配商户在链上的手续费规则，指定链的规则优先于所有链的规则
+func MatchFeeRule(merchantId uint64, channel string) (*mdb.FeeRule, error) {
+	rules, err := data.GetEnabledFeeRules(merchantId, channel)
+	if err != nil {
+		return nil, err
+	}
+	return selectFeeRule(rules), nil
+}
+
+// selectFeeRule 从候选规则中选出手续费规则，指定链的规则优先，同样具体时取靠前的规则
+func selectFeeRule(rules []mdb.FeeRule) *mdb.FeeRule {
+	var matched *mdb.FeeRule
+	for i := range rules {
+		if matched == nil || (matched.Channel == "" && rules[i].Channel != "") {
+			matched = &rules[i]
+		}
+	}
+	return matched
+}
+
+// CalculateOrderFee 按手续费规则计算订单实收金额的手续费与净额，手续费按链的金额精度舍入且不超过实收金额
+func CalculateOrderFee(order *mdb.Orders, channel string, grossAmount float64) (*mdb.FeeLedger, error) {
+	rule, err := MatchFeeRule(order.MerchantId, channel)
+	if err != nil {
+		return nil, err
+	}
+	return buildFeeLedger(order, channel, grossAmount, rule), nil
+}
+
+// buildFeeLedger 按规则生成手续费流水，未匹配规则时手续费为0
+func buildFeeLedger(order *mdb.Orders, channel string, grossAmount float64, rule *mdb.FeeRule) *mdb.FeeLedger {
+	gross := decimal.NewFromFloat(grossAmount)
+	ledger := &mdb.FeeLedger{
+		MerchantId:  order.MerchantId,
+		TradeId:     order.TradeId,
+		OrderId:     order.OrderId,
+		Channel:     channel,
+		GrossAmount: grossAmount,
+		NetAmount:   grossAmount,
+	}
+	if rule == nil {
+		return ledger
+	}
+	fee := gross.Mul(decimal.NewFromFloat(rule.Percent)).Div(decimal.NewFromInt(100)).
+		Add(decimal.NewFromFloat(rule.FixedFee)).
+		Round(config.GetAmountRule(channel).Precision)
+	if fee.GreaterThan(gross) {
+		fee = gross
+	}
+	ledger.FeeRuleId = rule.ID
+	ledger.Percent = rule.Percent
+	ledger.FixedFee = rule.FixedFee
+	ledger.FeeAmount = fee.InexactFloat64()
+	ledger.NetAmount = gross.Sub(fee).InexactFloat64()
+	return ledger
+}
+
+// GetFeeRuleList 按条件分页获取手续费规则
+func GetFeeRuleList(req *request.FeeRuleListRequest) ([]mdb.FeeRule, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	rules, total, err := data.GetFeeRuleList(req.MerchantId, req.Channel, p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return rules, page.GetPagination(p, pageSize, total), nil
+}
+
+// SaveFeeRule 新增或修改手续费规则，仅影响之后支付成功的订单
+func SaveFeeRule(req *request.FeeRuleSaveRequest) (*mdb.FeeRule, error) {
+	if req.Channel != "" && !model.IsChainSupported(req.Channel) {
+		return nil, constant.ChannelNotSupportErr
+	}
+	rule := new(mdb.FeeRule)
+	if req.Id > 0 {
+		var err error
+		rule, err = data.GetFeeRuleById(req.Id)
+		if err != nil {
+			return nil, err
+		}
+		if rule.ID <= 0 {
+			return nil, constant.FeeRuleNotExists
+		}
+	} else {
+		if err := checkMerchantUsable(req.MerchantId); err != nil {
+			return nil, err
+		}
+		rule.MerchantId = req.MerchantId
+	}
+	rule.Channel = req.Channel
+	rule.Percent = req.Percent
+	rule.FixedFee = req.FixedFee
+	rule.Status = req.Status
+	if rule.Status == 0 {
+		rule.Status = mdb.FeeRuleStatusEnable
+	}
+	if err := data.SaveFeeRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteFeeRule 删除手续费规则
+func DeleteFeeRule(req *request.FeeRuleDeleteRequest) error {
+	rule, err := data.GetFeeRuleById(req.Id)
+	if err != nil {
+		return err
+	}
+	if rule.ID <= 0 {
+		return constant.FeeRuleNotExists
+	}
+	return data.DeleteFeeRuleById(rule.ID)
+}
+
+// GetFeeLedgerList 分页获取手续费台账
+func GetFeeLedgerList(req *request.FeeLedgerRequest) ([]mdb.FeeLedger, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	ledgers, total, err := data.GetFeeLedgerList(req.MerchantId, req.Channel, req.StartTime, req.EndTime, p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return ledgers, page.GetPagination(p, pageSize, total), nil
+}
+
+// GetFeeStatement 汇总商户时间段内的手续费，按链分别统计并计算合计
+func GetFeeStatement(req *request.FeeStatementRequest) (*response.FeeStatementResponse, error) {
+	rows, err := data.GetFeeStatement(req.MerchantId, req.Channel, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	resp := &response.FeeStatementResponse{
+		MerchantId: req.MerchantId,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Channels:   []response.FeeStatementItem{},
+	}
+	gross, fee, net := decimal.Zero, decimal.Zero, decimal.Zero
+	for _, row := range rows {
+		resp.Channels = append(resp.Channels, response.FeeStatementItem{
+			Channel:     row.Channel,
+			OrderCount:  row.OrderCount,
+			GrossAmount: row.GrossAmount,
+			FeeAmount:   row.FeeAmount,
+			NetAmount:   row.NetAmount,
+		})
+		resp.Total.OrderCount += row.OrderCount
+		gross = gross.Add(decimal.NewFromFloat(row.GrossAmount))
+		fee = fee.Add(decimal.NewFromFloat(row.FeeAmount))
+		net = net.Add(decimal.NewFromFloat(row.NetAmount))
+	}
+	resp.Total.GrossAmount = gross.InexactFloat64()
+	resp.Total.FeeAmount = fee.InexactFloat64()
+	resp.Total.NetAmount = net.InexactFloat64()
+	return resp, nil
+}
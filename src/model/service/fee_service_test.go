@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/assimon/luuu/model/mdb"
+)
+
+func TestSelectFeeRule(t *testing.T) {
+	rule := func(id uint64, channel string) mdb.FeeRule {
+		r := mdb.FeeRule{Channel: channel}
+		r.ID = id
+		return r
+	}
+	tests := []struct {
+		name  string
+		rules []mdb.FeeRule
+		want  uint64 // 0为未匹配
+	}{
+		{"no rule", nil, 0},
+		{"all channels", []mdb.FeeRule{rule(1, "")}, 1},
+		{"channel over all channels", []mdb.FeeRule{rule(1, ""), rule(2, "tron")}, 2},
+		{"channel first", []mdb.FeeRule{rule(2, "tron"), rule(1, "")}, 2},
+		{"tie keeps first", []mdb.FeeRule{rule(1, "tron"), rule(2, "tron")}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uint64
+			if matched := selectFeeRule(tt.rules); matched != nil {
+				got = matched.ID
+			}
+			if got != tt.want {
+				t.Errorf("selectFeeRule = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFeeLedger(t *testing.T) {
+	order := &mdb.Orders{MerchantId: 2, TradeId: "T1", OrderId: "O1"}
+	tests := []struct {
+		name      string
+		precision interface{}
+		gross     float64
+		rule      *mdb.FeeRule
+		fee       float64
+		net       float64
+	}{
+		{"no rule", "", 100, nil, 0, 100},
+		{"percent", "", 100, &mdb.FeeRule{Percent: 0.5}, 0.5, 99.5},
+		{"percent and fixed", "", 20.01, &mdb.FeeRule{Percent: 1, FixedFee: 0.1}, 0.3, 19.71},
+		{"rounded to precision", "", 10.33, &mdb.FeeRule{Percent: 0.15}, 0.02, 10.31},
+		{"chain precision", 4, 10.33, &mdb.FeeRule{Percent: 0.15}, 0.0155, 10.3145},
+		{"capped at gross", "", 0.5, &mdb.FeeRule{FixedFee: 1}, 0.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestConfig(t, map[string]interface{}{"amount_precision": tt.precision})
+			ledger := buildFeeLedger(order, "tron", tt.gross, tt.rule)
+			if ledger.MerchantId != 2 || ledger.TradeId != "T1" || ledger.OrderId != "O1" || ledger.Channel != "tron" || ledger.GrossAmount != tt.gross {
+				t.Errorf("ledger = %+v, want order fields copied", ledger)
+			}
+			if ledger.FeeAmount != tt.fee || ledger.NetAmount != tt.net {
+				t.Errorf("fee, net = %v, %v, want %v, %v", ledger.FeeAmount, ledger.NetAmount, tt.fee, tt.net)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"os"
+	"testing"
+
+	"github.com/assimon/luuu/util/log"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	log.Sugar = zap.NewNop().Sugar()
+	os.Exit(m.Run())
+}
+
+// setTestConfig 临时设置配置项，测试结束后清空
+func setTestConfig(t *testing.T, values map[string]interface{}) {
+	for key, value := range values {
+		viper.Set(key, value)
+	}
+	t.Cleanup(func() {
+		for key := range values {
+			viper.Set(key, "")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/page"
+)
+
+// MerchantNotifyEventsNone 商户默认不额外回调任何事件
+const MerchantNotifyEventsNone = "none"
+
+// GetMerchantList 分页获取商户
+func GetMerchantList(req *request.MerchantListRequest) ([]mdb.Merchant, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	merchants, total, err := data.GetMerchantList(p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return merchants, page.GetPagination(p, pageSize, total), nil
+}
+
+// SaveMerchant 新增或修改商户，停用后该商户的api密钥无法再调用接口
+func SaveMerchant(req *request.MerchantSaveRequest) (*mdb.Merchant, error) {
+	merchant := new(mdb.Merchant)
+	if req.Id > 0 {
+		var err error
+		merchant, err = data.GetMerchantById(req.Id)
+		if err != nil {
+			return nil, err
+		}
+		if merchant.ID <= 0 {
+			return nil, constant.MerchantNotExists
+		}
+	}
+	notifyEvents := ""
+	if req.NotifyEvents != "" {
+		var err error
+		notifyEvents, err = NormalizeNotifyEvents(strings.Split(req.NotifyEvents, ","))
+		if err != nil {
+			return nil, err
+		}
+		// 保留 none 以区分不额外回调任何事件与使用全局配置
+		if notifyEvents == "" {
+			notifyEvents = MerchantNotifyEventsNone
+		}
+	}
+	merchant.Name = req.Name
+	merchant.NotifyUrl = req.NotifyUrl
+	merchant.NotifyEvents = notifyEvents
+	merchant.TgChatIds = req.TgChatIds
+	merchant.Status = req.Status
+	if merchant.Status == 0 {
+		merchant.Status = mdb.MerchantStatusEnable
+	}
+	if err := data.SaveMerchant(merchant); err != nil {
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// GetMerchantNotifyDefaults 商户的默认回调地址与默认回调事件，默认商户使用全局配置
+func GetMerchantNotifyDefaults(merchantId uint64) (string, []string, error) {
+	if merchantId == mdb.DefaultMerchantId {
+		return "", config.GetNotifyEvents(), nil
+	}
+	merchant, err := data.GetMerchantById(merchantId)
+	if err != nil {
+		return "", nil, err
+	}
+	if merchant.ID <= 0 {
+		return "", nil, constant.MerchantNotExists
+	}
+	if merchant.NotifyEvents == "" {
+		return merchant.NotifyUrl, config.GetNotifyEvents(), nil
+	}
+	return merchant.NotifyUrl, strings.Split(merchant.NotifyEvents, ","), nil
+}
+
+// checkMerchantUsable 校验商户存在且已启用，默认商户始终可用
+func checkMerchantUsable(merchantId uint64) error {
+	if merchantId == mdb.DefaultMerchantId {
+		return nil
+	}
+	merchant, err := data.GetMerchantById(merchantId)
+	if err != nil {
+		return err
+	}
+	if merchant.ID <= 0 || merchant.Status != mdb.MerchantStatusEnable {
+		return constant.MerchantNotExists
+	}
+	return nil
+}
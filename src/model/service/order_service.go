@@ -3,7 +3,7 @@ package service
 import (
 	"fmt"
 	"math/rand"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/assimon/luuu/config"
@@ -16,6 +16,8 @@ import (
 	"github.com/assimon/luuu/mq"
 	"github.com/assimon/luuu/mq/handle"
 	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/log"
 	"github.com/assimon/luuu/util/math"
 	"github.com/golang-module/carbon/v2"
 	"github.com/hibiken/asynq"
@@ -23,91 +25,165 @@ import (
 )
 
 const (
-	CnyMinimumPaymentAmount  = 0.01 // cny最低支付金额
-	UsdtMinimumPaymentAmount = 0.01 // usdt最低支付金额
-	UsdtAmountPerIncrement   = 0.01 // usdt每次递增金额
-	IncrementalMaximumNumber = 100  // 最大递增次数
+	CnyMinimumPaymentAmount  = 0.01  // cny最低支付金额
+	UsdtMinimumPaymentAmount = 0.01  // usdt最低支付金额
+	MaxCandidateAmountSteps  = 10000 // 最多偏移次数
+	OrderMetadataMaxSize     = 2048  // 商户自定义数据最大字节数
 )
 
-var gCreateTransactionLock sync.Mutex
-
 // CreateTransaction 创建订单
+// 钱包金额通过 redis 原子占用，多实例部署时无需进程内加锁
+// 开启收银台选择网络且未指定链时，订单暂不分配钱包，由客户在收银台选择网络后再分配
 func CreateTransaction(req *request.CreateTransactionRequest) (*response.CreateTransactionResponse, error) {
-	gCreateTransactionLock.Lock()
-	defer gCreateTransactionLock.Unlock()
 	payAmount := math.MustParsePrecFloat64(req.Amount, 2)
-	// 确定汇率
-	decimalRate, err := decimal.NewFromString(req.ExchangeRate)
-	if err != nil || decimalRate.LessThanOrEqual(decimal.Zero) {
-		decimalRate = decimal.NewFromFloat(config.GetUsdtRate())
-	}
-	// 按照汇率转化USDT
-	decimalPayAmount := decimal.NewFromFloat(payAmount)
-	decimalUsdt := decimalPayAmount.Div(decimalRate)
-	// cny 是否可以满足最低支付金额
-	if decimalPayAmount.Cmp(decimal.NewFromFloat(CnyMinimumPaymentAmount)) == -1 {
-		return nil, constant.PayAmountErr
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = config.GetUsdtRateDefaultCurrency()
 	}
-	// Usdt是否可以满足最低支付金额
-	if decimalUsdt.Cmp(decimal.NewFromFloat(UsdtMinimumPaymentAmount)) == -1 {
+	// 法币 是否可以满足最低支付金额
+	if decimal.NewFromFloat(payAmount).Cmp(decimal.NewFromFloat(CnyMinimumPaymentAmount)) == -1 {
 		return nil, constant.PayAmountErr
 	}
-	// 已经存在了的交易
-	exist, err := data.GetOrderInfoByOrderId(req.OrderId)
+	channel := req.Channel
+	if channel == "" && !config.GetCheckoutSelectChannel() {
+		channel = model.ChainNamePolygonPOS
+	}
+	if channel != "" && !model.IsChainSupported(channel) {
+		return nil, constant.ChannelNotSupportErr
+	}
+	var (
+		price       *ChannelPrice
+		decimalRate decimal.Decimal
+		rateSource  string
+		rateAt      carbon.Time
+		err         error
+	)
+	if req.QuoteId != "" {
+		// 按报价锁定的汇率与金额下单
+		quote, err := GetQuote(req.QuoteId)
+		if err != nil {
+			return nil, err
+		}
+		if quote.MerchantId != req.MerchantId {
+			return nil, constant.QuoteNotExists
+		}
+		if channel != "" {
+			price, err = GetQuoteChannelPrice(quote, payAmount, currency, channel)
+		} else if quote.Amount != payAmount || quote.Currency != currency {
+			err = constant.QuoteMismatchErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		decimalRate = decimal.NewFromFloat(quote.Rate)
+		rateSource, rateAt = quote.RateSource, carbon.Time{Carbon: quote.RateAt.Carbon}
+	} else {
+		// 确定汇率
+		rateSource, rateAt = RateSourceRequest, carbon.Time{Carbon: carbon.Now()}
+		decimalRate, err = decimal.NewFromString(req.ExchangeRate)
+		if err != nil || decimalRate.LessThanOrEqual(decimal.Zero) {
+			usdtRate, err := GetUsdtRate(currency)
+			if err != nil {
+				return nil, err
+			}
+			decimalRate = decimal.NewFromFloat(usdtRate.Rate)
+			rateSource, rateAt = usdtRate.Source, carbon.Time{Carbon: usdtRate.UpdatedAt.Carbon}
+		}
+		if channel != "" {
+			// 匹配定价规则，按照汇率转化USDT后加价并舍入
+			price, err = CalculateChannelPrice(req.MerchantId, channel, currency, payAmount, decimalRate)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// 未传回调地址与回调事件时使用商户的默认设置
+	notifyUrl, notifyEvents, err := GetMerchantNotifyDefaults(req.MerchantId)
 	if err != nil {
 		return nil, err
 	}
-	if exist.ID > 0 {
-		return nil, constant.OrderAlreadyExists
+	if req.NotifyUrl != "" {
+		notifyUrl = req.NotifyUrl
 	}
-	// 有无可用钱包
-	channel := req.Channel
-	if channel == "" {
-		channel = model.ChainNamePolygonPOS
+	if notifyUrl == "" {
+		return nil, constant.NotifyUrlEmptyErr
+	}
+	if req.NotifyEvents != "" {
+		notifyEvents = strings.Split(req.NotifyEvents, ",")
 	}
-	walletAddress, err := data.GetAvailableWallet(channel)
+	notifyEvent, err := NormalizeNotifyEvents(notifyEvents)
 	if err != nil {
 		return nil, err
 	}
-	if len(walletAddress) <= 0 {
-		return nil, constant.NotAvailableWalletAddress
+	metadata, err := NormalizeOrderMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
 	}
-
-	amount := math.MustParsePrecFloat64(decimalUsdt.InexactFloat64(), 2)
-	availableToken, availableAmount, err := CalculateAvailableWalletAndAmount(amount, walletAddress)
+	// 已经存在了的交易，订单号在商户内唯一
+	exist, err := data.GetMerchantOrderByOrderId(req.MerchantId, req.OrderId)
 	if err != nil {
 		return nil, err
 	}
-	if availableToken == "" {
-		return nil, constant.NotAvailableAmountErr
+	if exist.ID > 0 {
+		return nil, constant.OrderAlreadyExists
+	}
+	// 校验全部通过后再消耗报价，避免参数错误的请求作废报价
+	if req.QuoteId != "" {
+		if err = ConsumeQuote(req.QuoteId); err != nil {
+			return nil, err
+		}
 	}
-	tx := dao.Mdb.Begin()
 	order := &mdb.Orders{
-		TradeId:              GenerateCode(),
-		OrderId:              req.OrderId,
-		Amount:               req.Amount,
-		ActualAmount:         availableAmount,
-		TokenWithChainPrefix: channel + ":" + availableToken,
-		Status:               mdb.StatusWaitPay,
-		NotifyUrl:            req.NotifyUrl,
-		RedirectUrl:          req.RedirectUrl,
+		MerchantId:    req.MerchantId,
+		TradeId:       GenerateCode(),
+		OrderId:       req.OrderId,
+		Amount:        req.Amount,
+		Status:        mdb.StatusWaitPay,
+		NotifyUrl:     notifyUrl,
+		RedirectUrl:   req.RedirectUrl,
+		Currency:      currency,
+		RawRate:       decimalRate.InexactFloat64(),
+		RateSource:    rateSource,
+		RateAt:        rateAt,
+		ExpiredAt:     NewOrderExpiredAt(),
+		NotifyEvents:  notifyEvent,
+		ApiKeyId:      req.ApiKeyId,
+		CustomerEmail: req.CustomerEmail,
+		CustomerName:  req.CustomerName,
+		Metadata:      metadata,
 	}
-	err = data.CreateOrderWithTransaction(tx, order)
-	if err != nil {
-		tx.Rollback()
-		return nil, err
+	if price != nil {
+		// 分配钱包并占用金额
+		availableToken, availableAmount, err := AllocateWalletAndAmount(order.MerchantId, order.TradeId, channel, price.Usdt.InexactFloat64(), config.GetOrderExpirationTimeDuration())
+		if err != nil {
+			return nil, err
+		}
+		order.TokenWithChainPrefix = channel + ":" + availableToken
+		order.ActualAmount = availableAmount
+		order.PricingRuleId = price.PricingRuleId
+		order.MarkupPercent = price.MarkupPercent
+		order.FixedFee = price.FixedFee
+		order.Rounding = price.Rounding
+	}
+	// 先投递超时过期任务再创建订单，投递失败时不创建订单，避免订单无法过期、金额一直被占用
+	// 订单创建失败时任务执行时查询不到待支付订单，不做处理
+	err = EnqueueOrderExpiration(order.TradeId, order.ExpiredAt, config.GetOrderExpirationTimeDuration())
+	if err == nil {
+		err = data.CreateOrderWithTransaction(dao.Mdb, order)
 	}
-	// 锁定支付池
-	err = data.LockTransaction(order.TokenWithChainPrefix, order.TradeId, availableAmount, config.GetOrderExpirationTimeDuration())
 	if err != nil {
-		tx.Rollback()
+		// 释放已占用的钱包金额
+		if order.TokenWithChainPrefix != "" {
+			if unlockErr := data.UnLockTransaction(order.TokenWithChainPrefix, order.TradeId, order.ActualAmount); unlockErr != nil {
+				log.Sugar.Error(unlockErr)
+			}
+		}
+		if data.IsDuplicateKeyErr(err) {
+			return nil, constant.OrderAlreadyExists
+		}
 		return nil, err
 	}
-	tx.Commit()
-	// 超时过期消息队列
-	orderExpirationQueue, _ := handle.NewOrderExpirationQueue(order.TradeId)
-	mq.MClient.Enqueue(orderExpirationQueue, asynq.ProcessIn(config.GetOrderExpirationTimeDuration()))
-	ExpirationTime := carbon.Now().AddMinutes(config.GetOrderExpirationTime()).Timestamp()
+	ExpirationTime := order.ExpiredAt.Timestamp()
 	resp := &response.CreateTransactionResponse{
 		TradeId:        order.TradeId,
 		OrderId:        order.OrderId,
@@ -120,11 +196,85 @@ func CreateTransaction(req *request.CreateTransactionRequest) (*response.CreateT
 	return resp, nil
 }
 
-// OrderProcessing 成功处理订单
+// NormalizeOrderMetadata 校验商户自定义数据须为json对象，压缩后不超过最大字节数，数字按原文保留
+func NormalizeOrderMetadata(raw []byte) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var metadata map[string]interface{}
+	if err := json.CjsonNumber.Unmarshal(raw, &metadata); err != nil {
+		return "", constant.OrderMetadataErr
+	}
+	if len(metadata) == 0 {
+		return "", nil
+	}
+	content, err := json.CjsonNumber.Marshal(metadata)
+	if err != nil {
+		return "", constant.OrderMetadataErr
+	}
+	if len(content) > OrderMetadataMaxSize {
+		return "", constant.OrderMetadataErr
+	}
+	return string(content), nil
+}
+
+// EnqueueOrderExpiration 投递订单超时过期任务
+func EnqueueOrderExpiration(tradeId string, expiredAt carbon.Time, processIn time.Duration) error {
+	orderExpirationQueue, err := handle.NewOrderExpirationQueue(tradeId, expiredAt.Timestamp())
+	if err == nil {
+		_, err = mq.MClient.Enqueue(orderExpirationQueue, asynq.ProcessIn(processIn))
+	}
+	if err != nil {
+		log.Sugar.Errorf("[order] enqueue expiration trade_id:%s err:%s", tradeId, err.Error())
+	}
+	return err
+}
+
+// NewOrderExpiredAt 从当前时间计算订单过期时间，精确到秒以便与数据库中的值比较
+func NewOrderExpiredAt() carbon.Time {
+	return carbon.Time{Carbon: carbon.CreateFromTimestamp(carbon.Now().AddMinutes(config.GetOrderExpirationTime()).Timestamp())}
+}
+
+// AllocateWalletAndAmount 按分配策略选择商户在链下的可用钱包，并为交易号原子占用钱包金额
+func AllocateWalletAndAmount(merchantId uint64, tradeId, channel string, amount float64, expirationTime time.Duration) (string, float64, error) {
+	walletAddress, err := data.GetMerchantAvailableWallet(merchantId, channel)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(walletAddress) <= 0 {
+		return "", 0, constant.NotAvailableWalletAddress
+	}
+	// 排除已达每日上限的钱包，并按分配策略排序
+	walletAddress, err = FilterWalletByDailyLimit(amount, walletAddress)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(walletAddress) <= 0 {
+		return "", 0, constant.WalletDailyLimitErr
+	}
+	walletAddress, err = SortWalletByStrategy(channel, walletAddress)
+	if err != nil {
+		return "", 0, err
+	}
+	availableToken, availableAmount, err := CalculateAvailableWalletAndAmount(tradeId, amount, walletAddress, config.GetAmountRule(channel), expirationTime)
+	if err != nil {
+		return "", 0, err
+	}
+	if availableToken == "" {
+		return "", 0, constant.NotAvailableAmountErr
+	}
+	if err = data.TouchWalletLastUsed(channel, availableToken); err != nil {
+		log.Sugar.Error(err)
+	}
+	return availableToken, availableAmount, nil
+}
+
+// OrderProcessing 成功处理订单，同时按手续费规则记录手续费台账
 func OrderProcessing(req *request.OrderProcessingRequest) error {
 	tx := dao.Mdb.Begin()
 	exist, err := data.GetOrderByBlockIdWithTransaction(tx, req.BlockTransactionId)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 	if exist.ID > 0 {
@@ -132,65 +282,156 @@ func OrderProcessing(req *request.OrderProcessingRequest) error {
 		return constant.OrderBlockAlreadyProcess
 	}
 	// 标记订单成功
-	err = data.OrderSuccessWithTransaction(tx, req)
+	ok, err := data.OrderSuccessWithTransaction(tx, req)
+	if err == nil && !ok {
+		err = constant.OrderNotWaitPayErr
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// 计提平台手续费
+	order, err := data.GetOrderByTradeIdWithTransaction(tx, req.TradeId)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
-	// 解锁交易
-	err = data.UnLockTransaction(req.TokenWithChainPrefix, req.Amount)
+	channel := strings.SplitN(req.TokenWithChainPrefix, ":", 2)[0]
+	ledger, err := CalculateOrderFee(order, channel, req.Amount)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
-	tx.Commit()
+	if err = data.CreateFeeLedgerWithTransaction(tx, ledger); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+	// 提交成功后再解锁订单占用的全部钱包金额，解锁失败时金额在订单过期后自动释放
+	if err = data.UnLockOrderTransactions(order); err != nil {
+		log.Sugar.Error(err)
+	}
 	return nil
 }
 
-// CalculateAvailableWalletAndAmount 计算可用钱包地址和金额
-func CalculateAvailableWalletAndAmount(amount float64, walletAddress []mdb.WalletAddress) (string, float64, error) {
-	availableToken := ""
-	availableAmount := amount
-	calculateAvailableWalletFunc := func(amount float64) (string, error) {
-		availableWallet := ""
-		for _, address := range walletAddress {
-			result, err := data.GetTradeIdByWalletAddressAndAmount(address.Channel+":"+address.Token, amount)
-			if err != nil {
-				return "", err
-			}
-			if result == "" {
-				availableWallet = address.Token
-				break
-			}
-		}
-		return availableWallet, nil
+// CalculateAvailableWalletAndAmount 计算可用钱包地址和金额，并为交易号原子占用该钱包金额
+func CalculateAvailableWalletAndAmount(tradeId string, amount float64, walletAddress []mdb.WalletAddress, rule config.AmountRule, expirationTime time.Duration) (string, float64, error) {
+	var tokenWithChainPrefixList []string
+	for _, address := range walletAddress {
+		tokenWithChainPrefixList = append(tokenWithChainPrefixList, address.Channel+":"+address.Token)
 	}
-	for i := 0; i < IncrementalMaximumNumber; i++ {
-		token, err := calculateAvailableWalletFunc(availableAmount)
-		if err != nil {
-			return "", 0, err
+	candidates := GenerateCandidateAmounts(amount, rule)
+	index, availableAmount, err := data.LockFirstAvailableTransaction(tokenWithChainPrefixList, tradeId, candidates, expirationTime)
+	if err != nil {
+		return "", 0, err
+	}
+	if index < 0 {
+		return "", 0, nil
+	}
+	return walletAddress[index].Token, availableAmount, nil
+}
+
+// GenerateCandidateAmounts 按金额区分规则生成候选金额，越靠前越优先
+func GenerateCandidateAmounts(amount float64, rule config.AmountRule) []float64 {
+	base := decimal.NewFromFloat(amount).Round(rule.Precision)
+	step := decimal.NewFromFloat(rule.Step).Round(rule.Precision)
+	minimum := decimal.NewFromFloat(UsdtMinimumPaymentAmount)
+	maxSteps := int(decimal.NewFromFloat(rule.MaxOffset).Div(step).IntPart())
+	if maxSteps > MaxCandidateAmountSteps {
+		maxSteps = MaxCandidateAmountSteps
+	}
+	candidates := []float64{base.InexactFloat64()}
+	for i := 1; i <= maxSteps; i++ {
+		offset := step.Mul(decimal.NewFromInt(int64(i)))
+		if rule.Direction == config.AmountDirectionUp || rule.Direction == config.AmountDirectionAlternate {
+			candidates = append(candidates, base.Add(offset).InexactFloat64())
 		}
-		// 拿不到可用钱包就累加金额
-		if token == "" {
-			decimalOldAmount := decimal.NewFromFloat(availableAmount)
-			decimalIncr := decimal.NewFromFloat(UsdtAmountPerIncrement)
-			availableAmount = decimalOldAmount.Add(decimalIncr).InexactFloat64()
-			continue
+		if rule.Direction == config.AmountDirectionDown || rule.Direction == config.AmountDirectionAlternate {
+			if down := base.Sub(offset); down.GreaterThanOrEqual(minimum) {
+				candidates = append(candidates, down.InexactFloat64())
+			}
 		}
-		availableToken = token
-		break
 	}
-	return availableToken, availableAmount, nil
+	return candidates
 }
 
 // GenerateCode 订单号生成
 func GenerateCode() string {
 	date := time.Now().Format("20060102")
-	r := rand.Intn(1000)
-	code := fmt.Sprintf("%s%d%03d", date, time.Now().UnixNano()/1e6, r)
+	r := rand.Intn(1000000)
+	code := fmt.Sprintf("%s%d%06d", date, time.Now().UnixNano()/1e6, r)
 	return code
 }
 
+// CancelOrder 取消商户的待支付订单，释放占用的钱包金额
+func CancelOrder(merchantId uint64, tradeId string) (*response.CancelOrderResponse, error) {
+	order, err := GetMerchantOrderInfoByTradeId(merchantId, tradeId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != mdb.StatusWaitPay {
+		return nil, constant.OrderCancelErr
+	}
+	ok, err := data.CancelOrderById(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, constant.OrderCancelErr
+	}
+	order.Status = mdb.StatusCancelled
+	if err = data.UnLockOrderTransactions(order); err != nil {
+		log.Sugar.Error(err)
+	}
+	if err = EnqueueOrderEvent(order, mdb.OrderEventCancelled); err != nil {
+		log.Sugar.Error(err)
+	}
+	resp := &response.CancelOrderResponse{
+		TradeId: order.TradeId,
+		OrderId: order.OrderId,
+		Status:  order.Status,
+	}
+	return resp, nil
+}
+
+// QueryOrder 通过交易号或客户交易id查询商户的订单
+func QueryOrder(req *request.OrderQueryRequest) (*response.OrderInfoResponse, error) {
+	var (
+		order *mdb.Orders
+		err   error
+	)
+	if req.TradeId != "" {
+		order, err = GetMerchantOrderInfoByTradeId(req.MerchantId, req.TradeId)
+	} else {
+		order, err = data.GetMerchantOrderByOrderId(req.MerchantId, req.OrderId)
+		if err == nil && order.ID <= 0 {
+			err = constant.OrderNotExists
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := &response.OrderInfoResponse{
+		TradeId:            order.TradeId,
+		OrderId:            order.OrderId,
+		Amount:             order.Amount,
+		Currency:           order.Currency,
+		ActualAmount:       order.ActualAmount,
+		Token:              order.TokenWithChainPrefix,
+		BlockTransactionId: order.BlockTransactionId,
+		Status:             order.Status,
+		CallbackConfirm:    order.CallBackConfirm,
+		ExpirationTime:     order.GetExpiredAt(config.GetOrderExpirationTime()).Timestamp(),
+		CreatedAt:          order.CreatedAt.Timestamp(),
+		CustomerEmail:      order.CustomerEmail,
+		CustomerName:       order.CustomerName,
+		Metadata:           order.GetMetadata(),
+	}
+	return resp, nil
+}
+
 // GetOrderInfoByTradeId 通过交易号获取订单
 func GetOrderInfoByTradeId(tradeId string) (*mdb.Orders, error) {
 	order, err := data.GetOrderInfoByTradeId(tradeId)
@@ -202,3 +443,15 @@ func GetOrderInfoByTradeId(tradeId string) (*mdb.Orders, error) {
 	}
 	return order, nil
 }
+
+// GetMerchantOrderInfoByTradeId 通过交易号获取商户的订单，其他商户的订单视为不存在
+func GetMerchantOrderInfoByTradeId(merchantId uint64, tradeId string) (*mdb.Orders, error) {
+	order, err := GetOrderInfoByTradeId(tradeId)
+	if err != nil {
+		return nil, err
+	}
+	if order.MerchantId != merchantId {
+		return nil, constant.OrderNotExists
+	}
+	return order, nil
+}
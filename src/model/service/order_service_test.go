@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/util/constant"
+)
+
+func TestGenerateCandidateAmounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		rule   config.AmountRule
+		want   []float64
+	}{
+		{
+			name:   "up",
+			amount: 10,
+			rule:   config.AmountRule{Step: 0.01, Precision: 2, MaxOffset: 0.03, Direction: config.AmountDirectionUp},
+			want:   []float64{10, 10.01, 10.02, 10.03},
+		},
+		{
+			name:   "down stops at minimum",
+			amount: 0.03,
+			rule:   config.AmountRule{Step: 0.01, Precision: 2, MaxOffset: 0.05, Direction: config.AmountDirectionDown},
+			want:   []float64{0.03, 0.02, 0.01},
+		},
+		{
+			name:   "alternate",
+			amount: 5,
+			rule:   config.AmountRule{Step: 0.01, Precision: 2, MaxOffset: 0.02, Direction: config.AmountDirectionAlternate},
+			want:   []float64{5, 5.01, 4.99, 5.02, 4.98},
+		},
+		{
+			name:   "amount rounded to precision",
+			amount: 1.23456,
+			rule:   config.AmountRule{Step: 0.0001, Precision: 4, MaxOffset: 0.0002, Direction: config.AmountDirectionUp},
+			want:   []float64{1.2346, 1.2347, 1.2348},
+		},
+		{
+			name:   "offset not a multiple of step",
+			amount: 1,
+			rule:   config.AmountRule{Step: 0.03, Precision: 2, MaxOffset: 0.1, Direction: config.AmountDirectionUp},
+			want:   []float64{1, 1.03, 1.06, 1.09},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateCandidateAmounts(tt.amount, tt.rule)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateCandidateAmounts(%v) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateCandidateAmountsMaxSteps(t *testing.T) {
+	rule := config.AmountRule{Step: 0.000001, Precision: 6, MaxOffset: 1, Direction: config.AmountDirectionUp}
+	got := GenerateCandidateAmounts(100, rule)
+	if len(got) != MaxCandidateAmountSteps+1 {
+		t.Fatalf("len = %d, want %d", len(got), MaxCandidateAmountSteps+1)
+	}
+	if last := got[len(got)-1]; last != 100.01 {
+		t.Errorf("last = %v, want 100.01", last)
+	}
+}
+
+func TestNormalizeOrderMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+		err  error
+	}{
+		{"empty", "", "", nil},
+		{"null", "null", "", nil},
+		{"empty object", "{}", "", nil},
+		{"object", `{"user":{"id":7},"tags":["vip"]}`, `{"tags":["vip"],"user":{"id":7}}`, nil},
+		{"keeps number precision", `{"big":12345678901234567890,"amount":1.50}`, `{"amount":1.50,"big":12345678901234567890}`, nil},
+		{"array", `["a"]`, "", constant.OrderMetadataErr},
+		{"string", `"a"`, "", constant.OrderMetadataErr},
+		{"invalid", `{"a":`, "", constant.OrderMetadataErr},
+		{"too large", `{"a":"` + strings.Repeat("x", OrderMetadataMaxSize) + `"}`, "", constant.OrderMetadataErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeOrderMetadata([]byte(tt.raw))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("NormalizeOrderMetadata(%s) err = %v, want %v", tt.raw, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeOrderMetadata(%s) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,20 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model"
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
 	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/log"
+	"github.com/golang-module/carbon/v2"
+	"github.com/shopspring/decimal"
 )
 
 // GetCheckoutCounterByTradeId 获取收银台详情，通过订单
@@ -20,36 +27,262 @@ func GetCheckoutCounterByTradeId(tradeId string) (*response.CheckoutCounterRespo
 	if orderInfo.ID <= 0 || orderInfo.Status != mdb.StatusWaitPay {
 		return nil, errors.New("不存在待支付订单或已过期！")
 	}
+	if orderInfo.TokenWithChainPrefix == "" {
+		return getSelectChannelCheckoutCounter(orderInfo)
+	}
 	channel := ""
 	token := orderInfo.TokenWithChainPrefix
+	checkoutMessage := ""
 	if strings.Count(token, ":") == 1 {
 		parts := strings.Split(token, ":")
 		channel = parts[0]
 		token = parts[1]
+		wallet, err := data.GetWalletAddressByToken(token, channel)
+		if err != nil {
+			return nil, err
+		}
+		checkoutMessage = wallet.CheckoutMessage
 	}
-	switch channel {
-	case model.ChainNamePolygonPOS:
-		channel = "Polygon PoS Chain (POL)"
-	case model.ChainNameAVAXC:
-		channel = "Avalanche (C-Chain)"
-	case model.ChainNameETH:
-		channel = "Ethereum - ERC20"
-	case model.ChainNameBSC:
-		channel = "BNB Smart Chain - BEP20"
-	case model.ChainNameTRC20:
-		channel = "TRON - TRC20"
-	case model.ChainNameAptos:
-		channel = "Aptos"
-	case model.ChainNameArbitrum:
-		channel = "Arbitrum One"
+	channels, err := getCheckoutChannels(orderInfo, channel)
+	if err != nil {
+		return nil, err
+	}
+	resp := &response.CheckoutCounterResponse{
+		TradeId:         orderInfo.TradeId,
+		ActualAmount:    orderInfo.ActualAmount,
+		Channel:         model.GetChainDisplayName(channel),
+		Token:           token,
+		ExpirationTime:  orderInfo.GetExpiredAt(config.GetOrderExpirationTime()).TimestampWithMillisecond(),
+		RedirectUrl:     orderInfo.RedirectUrl,
+		CheckoutMessage: checkoutMessage,
+		Channels:        channels,
+	}
+	return resp, nil
+}
+
+// getSelectChannelCheckoutCounter 未选择付款网络订单的收银台，按下单汇率展示各网络应付金额
+func getSelectChannelCheckoutCounter(orderInfo *mdb.Orders) (*response.CheckoutCounterResponse, error) {
+	channels, err := getCheckoutChannels(orderInfo, "")
+	if err != nil {
+		return nil, err
 	}
 	resp := &response.CheckoutCounterResponse{
 		TradeId:        orderInfo.TradeId,
-		ActualAmount:   orderInfo.ActualAmount,
-		Channel:        channel,
-		Token:          token,
-		ExpirationTime: orderInfo.CreatedAt.AddMinutes(config.GetOrderExpirationTime()).TimestampWithMillisecond(),
+		ExpirationTime: orderInfo.GetExpiredAt(config.GetOrderExpirationTime()).TimestampWithMillisecond(),
 		RedirectUrl:    orderInfo.RedirectUrl,
+		SelectChannel:  true,
+		Amount:         orderInfo.Amount,
+		Currency:       strings.ToUpper(orderInfo.Currency),
+		Channels:       channels,
+	}
+	return resp, nil
+}
+
+// getCheckoutChannels 按下单汇率计算有启用钱包的各网络应付金额，排除订单当前的网络
+func getCheckoutChannels(orderInfo *mdb.Orders, currentChannel string) ([]response.CheckoutChannel, error) {
+	// 未记录汇率的历史订单无法重新计算金额
+	if orderInfo.RawRate <= 0 {
+		return nil, nil
+	}
+	walletCount, err := data.CountAvailableWalletByChannel(orderInfo.MerchantId)
+	if err != nil {
+		return nil, err
+	}
+	var channels []response.CheckoutChannel
+	for _, chain := range model.Chains {
+		if chain.Code == currentChannel || walletCount[chain.Code] <= 0 {
+			continue
+		}
+		checkoutChannel := response.CheckoutChannel{
+			Channel:     chain.Code,
+			DisplayName: chain.DisplayName,
+			Asset:       model.AssetUsdt,
+		}
+		price, err := CalculateChannelPrice(orderInfo.MerchantId, chain.Code, orderInfo.Currency, orderInfo.Amount, decimal.NewFromFloat(orderInfo.RawRate))
+		if err == nil {
+			checkoutChannel.ActualAmount = price.Usdt.InexactFloat64()
+			checkoutChannel.Available = true
+		}
+		channels = append(channels, checkoutChannel)
+	}
+	return channels, nil
+}
+
+// SelectOrderChannel 客户在收银台选择付款网络，此时才分配钱包并占用金额
+func SelectOrderChannel(tradeId, channel string) error {
+	orderInfo, err := data.GetOrderInfoByTradeId(tradeId)
+	if err != nil {
+		return err
+	}
+	if orderInfo.ID <= 0 || orderInfo.Status != mdb.StatusWaitPay {
+		return constant.OrderNotExists
+	}
+	if orderInfo.TokenWithChainPrefix != "" {
+		return constant.OrderChannelSelectedErr
+	}
+	if !model.IsChainSupported(channel) {
+		return constant.ChannelNotSupportErr
+	}
+	if orderInfo.RawRate <= 0 {
+		return constant.RateAmountErr
+	}
+	price, err := CalculateChannelPrice(orderInfo.MerchantId, channel, orderInfo.Currency, orderInfo.Amount, decimal.NewFromFloat(orderInfo.RawRate))
+	if err != nil {
+		return err
+	}
+	// 钱包金额只需占用到订单过期
+	expirationTime := orderInfo.GetExpiredAt(config.GetOrderExpirationTime()).Carbon2Time().Sub(time.Now())
+	if expirationTime <= 0 {
+		return constant.OrderNotExists
+	}
+	availableToken, availableAmount, err := AllocateWalletAndAmount(orderInfo.MerchantId, orderInfo.TradeId, channel, price.Usdt.InexactFloat64(), expirationTime)
+	if err != nil {
+		return err
+	}
+	tokenWithChainPrefix := channel + ":" + availableToken
+	ok, err := data.UpdateOrderChannelById(orderInfo.ID, "", map[string]interface{}{
+		"token":           tokenWithChainPrefix,
+		"actual_amount":   availableAmount,
+		"pricing_rule_id": price.PricingRuleId,
+		"markup_percent":  price.MarkupPercent,
+		"fixed_fee":       price.FixedFee,
+		"rounding":        price.Rounding,
+	})
+	if err == nil && !ok {
+		err = constant.OrderChannelSelectedErr
+	}
+	if err != nil {
+		// 并发选择或保存失败时释放已占用的钱包金额
+		if unlockErr := data.UnLockTransaction(tokenWithChainPrefix, orderInfo.TradeId, availableAmount); unlockErr != nil {
+			log.Sugar.Error(unlockErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// SwitchOrderChannel 将待支付订单切换到其他付款网络，重新分配钱包、释放原钱包金额
+// 商户接口切换时重置过期时间，收银台切换无需鉴权，保持原过期时间，避免订单被无限延期
+func SwitchOrderChannel(tradeId, channel, source string) (*mdb.Orders, error) {
+	orderInfo, err := data.GetOrderInfoByTradeId(tradeId)
+	if err != nil {
+		return nil, err
+	}
+	if orderInfo.ID <= 0 || orderInfo.Status != mdb.StatusWaitPay {
+		return nil, constant.OrderNotExists
+	}
+	if !model.IsChainSupported(channel) {
+		return nil, constant.ChannelNotSupportErr
+	}
+	if strings.SplitN(orderInfo.TokenWithChainPrefix, ":", 2)[0] == channel {
+		return nil, constant.OrderChannelSameErr
+	}
+	if orderInfo.RawRate <= 0 {
+		return nil, constant.RateAmountErr
+	}
+	price, err := CalculateChannelPrice(orderInfo.MerchantId, channel, orderInfo.Currency, orderInfo.Amount, decimal.NewFromFloat(orderInfo.RawRate))
+	if err != nil {
+		return nil, err
+	}
+	expiredAt := NewOrderExpiredAt()
+	expirationTime := config.GetOrderExpirationTimeDuration()
+	if source == mdb.ChannelSwitchSourceCheckout {
+		expiredAt = carbon.Time{Carbon: orderInfo.GetExpiredAt(config.GetOrderExpirationTime())}
+		// 钱包金额只需占用到订单过期
+		expirationTime = expiredAt.Carbon2Time().Sub(time.Now())
+		if expirationTime <= 0 {
+			return nil, constant.OrderNotExists
+		}
+	}
+	availableToken, availableAmount, err := AllocateWalletAndAmount(orderInfo.MerchantId, orderInfo.TradeId, channel, price.Usdt.InexactFloat64(), expirationTime)
+	if err != nil {
+		return nil, err
+	}
+	tokenWithChainPrefix := channel + ":" + availableToken
+	// 先投递新的过期任务再切换，投递失败时不切换；过期时间重置后原过期任务作废，切换失败时新任务不会提前使订单过期
+	err = EnqueueOrderExpiration(orderInfo.TradeId, expiredAt, expirationTime)
+	ok := false
+	if err == nil {
+		ok, err = data.UpdateOrderChannelById(orderInfo.ID, orderInfo.TokenWithChainPrefix, map[string]interface{}{
+			"token":           tokenWithChainPrefix,
+			"actual_amount":   availableAmount,
+			"pricing_rule_id": price.PricingRuleId,
+			"markup_percent":  price.MarkupPercent,
+			"fixed_fee":       price.FixedFee,
+			"rounding":        price.Rounding,
+			"expired_at":      expiredAt,
+		})
+	}
+	if err == nil && !ok {
+		// 订单已被支付、过期或同时被切换
+		err = constant.OrderNotExists
+	}
+	if err != nil {
+		if unlockErr := data.UnLockTransaction(tokenWithChainPrefix, orderInfo.TradeId, availableAmount); unlockErr != nil {
+			log.Sugar.Error(unlockErr)
+		}
+		return nil, err
+	}
+	// 原钱包金额保留到原过期时间，客户切换前已发起的转账仍可匹配订单，锁定到期后自动释放
+	err = data.CreateOrderChannelHistory(&mdb.OrderChannelHistory{
+		TradeId:          orderInfo.TradeId,
+		FromToken:        orderInfo.TokenWithChainPrefix,
+		FromActualAmount: orderInfo.ActualAmount,
+		FromExpiredAt:    carbon.Time{Carbon: orderInfo.GetExpiredAt(config.GetOrderExpirationTime())},
+		ToToken:          tokenWithChainPrefix,
+		ToActualAmount:   availableAmount,
+		Source:           source,
+	})
+	if err != nil {
+		log.Sugar.Error(err)
+		// 没有切换记录时无法匹配原钱包的转账，直接释放原钱包金额
+		if orderInfo.TokenWithChainPrefix != "" {
+			if unlockErr := data.UnLockTransaction(orderInfo.TokenWithChainPrefix, orderInfo.TradeId, orderInfo.ActualAmount); unlockErr != nil {
+				log.Sugar.Error(unlockErr)
+			}
+		}
+	}
+	return data.GetOrderInfoByTradeId(orderInfo.TradeId)
+}
+
+// IsOrderPaymentMatched 转账的钱包与金额是否匹配订单当前的付款信息，或切换付款网络前仍在占用期内的原付款信息
+func IsOrderPaymentMatched(order *mdb.Orders, tokenWithChainPrefix string, amount float64) (bool, error) {
+	paid := decimal.NewFromFloat(amount)
+	if order.TokenWithChainPrefix == tokenWithChainPrefix && decimal.NewFromFloat(order.ActualAmount).Equal(paid) {
+		return true, nil
+	}
+	histories, err := data.GetOrderChannelHistory(order.TradeId)
+	if err != nil {
+		return false, err
+	}
+	for _, history := range histories {
+		if history.FromToken != tokenWithChainPrefix || !decimal.NewFromFloat(history.FromActualAmount).Equal(paid) {
+			continue
+		}
+		if !history.FromExpiredAt.IsZero() && history.FromExpiredAt.Gt(carbon.Now()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SwitchOrderChannelByApi 商户接口切换订单付款网络
+func SwitchOrderChannelByApi(req *request.SwitchChannelRequest) (*response.CreateTransactionResponse, error) {
+	if _, err := GetMerchantOrderInfoByTradeId(req.MerchantId, req.TradeId); err != nil {
+		return nil, err
+	}
+	order, err := SwitchOrderChannel(req.TradeId, req.Channel, mdb.ChannelSwitchSourceApi)
+	if err != nil {
+		return nil, err
+	}
+	resp := &response.CreateTransactionResponse{
+		TradeId:        order.TradeId,
+		OrderId:        order.OrderId,
+		Amount:         order.Amount,
+		ActualAmount:   order.ActualAmount,
+		Token:          order.TokenWithChainPrefix,
+		ExpirationTime: order.ExpiredAt.Timestamp(),
+		PaymentUrl:     fmt.Sprintf("%s/pay/checkout-counter/%s", config.GetAppUri(), order.TradeId),
 	}
 	return resp, nil
 }
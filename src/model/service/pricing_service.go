@@ -0,0 +1,173 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
+)
+
+// MatchPricingRule 匹配商户链与法币的定价规则，同时指定链与法币的规则优先，其次为仅指定链、仅指定法币、通用规则
+func MatchPricingRule(merchantId uint64, channel, currency string) (*mdb.PricingRule, error) {
+	rules, err := data.GetEnabledPricingRules(merchantId, channel, currency)
+	if err != nil {
+		return nil, err
+	}
+	return selectPricingRule(rules), nil
+}
+
+// selectPricingRule 从候选规则中选出最具体的规则，同样具体时取靠前的规则
+func selectPricingRule(rules []mdb.PricingRule) *mdb.PricingRule {
+	var matched *mdb.PricingRule
+	matchedScore := -1
+	for i := range rules {
+		score := 0
+		if rules[i].Channel != "" {
+			score += 2
+		}
+		if rules[i].Currency != "" {
+			score++
+		}
+		if score > matchedScore {
+			matched, matchedScore = &rules[i], score
+		}
+	}
+	return matched
+}
+
+// CheckPricingAmount 校验订单法币金额是否在规则允许范围内
+func CheckPricingAmount(rule *mdb.PricingRule, amount float64) error {
+	if rule == nil {
+		return nil
+	}
+	if rule.MinAmount > 0 && amount < rule.MinAmount {
+		return constant.PricingAmountRangeErr
+	}
+	if rule.MaxAmount > 0 && amount > rule.MaxAmount {
+		return constant.PricingAmountRangeErr
+	}
+	return nil
+}
+
+// ApplyPricingRule 按规则加价、加手续费并舍入，未匹配规则时直接四舍五入
+func ApplyPricingRule(rule *mdb.PricingRule, usdt decimal.Decimal, precision int32) decimal.Decimal {
+	rounding := mdb.RoundingHalfUp
+	if rule != nil {
+		usdt = usdt.Mul(decimal.NewFromFloat(rule.MarkupPercent).Div(decimal.NewFromInt(100)).Add(decimal.NewFromInt(1)))
+		usdt = usdt.Add(decimal.NewFromFloat(rule.FixedFee))
+		rounding = rule.Rounding
+	}
+	switch rounding {
+	case mdb.RoundingUp:
+		return usdt.RoundCeil(precision)
+	case mdb.RoundingDown:
+		return usdt.RoundFloor(precision)
+	case mdb.RoundingHalfEven:
+		return usdt.RoundBank(precision)
+	default:
+		return usdt.Round(precision)
+	}
+}
+
+// ChannelPrice 链的定价结果
+type ChannelPrice struct {
+	Usdt          decimal.Decimal // 应付USDT，未经金额区分规则偏移
+	PricingRuleId uint64          // 应用的定价规则id，0为未应用
+	MarkupPercent float64         // 应用的加价百分比
+	FixedFee      float64         // 应用的固定手续费(usdt)
+	Rounding      string          // 应用的舍入方式
+}
+
+// CalculateChannelPrice 按商户链与法币匹配定价规则，将法币金额按汇率转化为应付USDT
+func CalculateChannelPrice(merchantId uint64, channel, currency string, payAmount float64, rate decimal.Decimal) (*ChannelPrice, error) {
+	pricingRule, err := MatchPricingRule(merchantId, channel, currency)
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckPricingAmount(pricingRule, payAmount); err != nil {
+		return nil, err
+	}
+	price := &ChannelPrice{
+		Usdt:     ApplyPricingRule(pricingRule, decimal.NewFromFloat(payAmount).Div(rate), config.GetAmountRule(channel).Precision),
+		Rounding: mdb.RoundingHalfUp,
+	}
+	// Usdt是否可以满足最低支付金额
+	if price.Usdt.Cmp(decimal.NewFromFloat(UsdtMinimumPaymentAmount)) == -1 {
+		return nil, constant.PayAmountErr
+	}
+	if pricingRule != nil {
+		price.PricingRuleId = pricingRule.ID
+		price.MarkupPercent = pricingRule.MarkupPercent
+		price.FixedFee = pricingRule.FixedFee
+		price.Rounding = pricingRule.Rounding
+	}
+	return price, nil
+}
+
+// GetPricingRuleList 按条件分页获取定价规则
+func GetPricingRuleList(req *request.PricingRuleListRequest) ([]mdb.PricingRule, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	rules, total, err := data.GetPricingRuleList(req.MerchantId, req.Channel, strings.ToLower(req.Currency), p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return rules, page.GetPagination(p, pageSize, total), nil
+}
+
+// SavePricingRule 新增或修改定价规则
+func SavePricingRule(req *request.PricingRuleSaveRequest) (*mdb.PricingRule, error) {
+	if req.Channel != "" && !model.IsChainSupported(req.Channel) {
+		return nil, constant.ChannelNotSupportErr
+	}
+	if req.MaxAmount > 0 && req.MinAmount > req.MaxAmount {
+		return nil, constant.PricingAmountRangeErr
+	}
+	rule := new(mdb.PricingRule)
+	if req.Id > 0 {
+		var err error
+		rule, err = data.GetPricingRuleById(req.Id)
+		if err != nil {
+			return nil, err
+		}
+		if rule.ID <= 0 || rule.MerchantId != req.MerchantId {
+			return nil, constant.PricingRuleNotExists
+		}
+	}
+	rule.MerchantId = req.MerchantId
+	rule.Channel = req.Channel
+	rule.Currency = strings.ToLower(req.Currency)
+	rule.MarkupPercent = req.MarkupPercent
+	rule.FixedFee = req.FixedFee
+	rule.Rounding = req.Rounding
+	if rule.Rounding == "" {
+		rule.Rounding = mdb.RoundingHalfUp
+	}
+	rule.MinAmount = req.MinAmount
+	rule.MaxAmount = req.MaxAmount
+	rule.Status = req.Status
+	if rule.Status == 0 {
+		rule.Status = mdb.PricingRuleStatusEnable
+	}
+	if err := data.SavePricingRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeletePricingRule 删除定价规则
+func DeletePricingRule(req *request.PricingRuleDeleteRequest) error {
+	rule, err := data.GetPricingRuleById(req.Id)
+	if err != nil {
+		return err
+	}
+	if rule.ID <= 0 || rule.MerchantId != req.MerchantId {
+		return constant.PricingRuleNotExists
+	}
+	return data.DeletePricingRuleById(rule.ID)
+}
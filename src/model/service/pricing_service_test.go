@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/shopspring/decimal"
+)
+
+func testPricingRule(id uint64, channel, currency string) mdb.PricingRule {
+	rule := mdb.PricingRule{Channel: channel, Currency: currency}
+	rule.ID = id
+	return rule
+}
+
+func TestSelectPricingRule(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []mdb.PricingRule
+		want  uint64 // 0为未匹配
+	}{
+		{"no rule", nil, 0},
+		{"generic", []mdb.PricingRule{testPricingRule(1, "", "")}, 1},
+		{"currency over generic", []mdb.PricingRule{testPricingRule(1, "", ""), testPricingRule(2, "", "cny")}, 2},
+		{"channel over currency", []mdb.PricingRule{testPricingRule(1, "", "cny"), testPricingRule(2, "tron", "")}, 2},
+		{"channel and currency first", []mdb.PricingRule{testPricingRule(1, "tron", ""), testPricingRule(2, "tron", "cny"), testPricingRule(3, "", "cny")}, 2},
+		{"tie keeps first", []mdb.PricingRule{testPricingRule(1, "tron", ""), testPricingRule(2, "tron", "")}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uint64
+			if rule := selectPricingRule(tt.rules); rule != nil {
+				got = rule.ID
+			}
+			if got != tt.want {
+				t.Errorf("selectPricingRule = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPricingAmount(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   *mdb.PricingRule
+		amount float64
+		err    error
+	}{
+		{"no rule", nil, 0.01, nil},
+		{"no range", &mdb.PricingRule{}, 1000000, nil},
+		{"below min", &mdb.PricingRule{MinAmount: 10}, 9.99, constant.PricingAmountRangeErr},
+		{"equals min", &mdb.PricingRule{MinAmount: 10}, 10, nil},
+		{"equals max", &mdb.PricingRule{MaxAmount: 100}, 100, nil},
+		{"above max", &mdb.PricingRule{MinAmount: 10, MaxAmount: 100}, 100.01, constant.PricingAmountRangeErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPricingAmount(tt.rule, tt.amount); !errors.Is(err, tt.err) {
+				t.Errorf("CheckPricingAmount(%v) = %v, want %v", tt.amount, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestApplyPricingRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      *mdb.PricingRule
+		usdt      string
+		precision int32
+		want      string
+	}{
+		{"no rule half up", nil, "7.125", 2, "7.13"},
+		{"markup and fee", &mdb.PricingRule{MarkupPercent: 1, FixedFee: 0.5, Rounding: mdb.RoundingHalfUp}, "100", 2, "101.5"},
+		{"discount", &mdb.PricingRule{MarkupPercent: -10, Rounding: mdb.RoundingHalfUp}, "100", 2, "90"},
+		{"half up", &mdb.PricingRule{Rounding: mdb.RoundingHalfUp}, "1.005", 2, "1.01"},
+		{"up", &mdb.PricingRule{Rounding: mdb.RoundingUp}, "1.001", 2, "1.01"},
+		{"down", &mdb.PricingRule{Rounding: mdb.RoundingDown}, "1.009", 2, "1"},
+		{"half even down", &mdb.PricingRule{Rounding: mdb.RoundingHalfEven}, "1.005", 2, "1"},
+		{"half even up", &mdb.PricingRule{Rounding: mdb.RoundingHalfEven}, "1.015", 2, "1.02"},
+		{"precision", &mdb.PricingRule{MarkupPercent: 0.5, Rounding: mdb.RoundingUp}, "13.888888", 4, "13.9584"},
+		{"unknown rounding", &mdb.PricingRule{Rounding: "bogus"}, "2.345", 2, "2.35"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyPricingRule(tt.rule, decimal.RequireFromString(tt.usdt), tt.precision)
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("ApplyPricingRule(%s) = %s, want %s", tt.usdt, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/math"
+	"github.com/golang-module/carbon/v2"
+	"github.com/shopspring/decimal"
+)
+
+// CreateQuote 按当前汇率与定价规则报价，不创建订单也不占用钱包
+func CreateQuote(req *request.QuoteRequest) (*response.QuoteResponse, error) {
+	payAmount := math.MustParsePrecFloat64(req.Amount, 2)
+	if decimal.NewFromFloat(payAmount).Cmp(decimal.NewFromFloat(CnyMinimumPaymentAmount)) == -1 {
+		return nil, constant.PayAmountErr
+	}
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = config.GetUsdtRateDefaultCurrency()
+	}
+	var channels []string
+	for _, chain := range model.Chains {
+		channels = append(channels, chain.Code)
+	}
+	if req.Channel != "" {
+		if !model.IsChainSupported(req.Channel) {
+			return nil, constant.ChannelNotSupportErr
+		}
+		channels = []string{req.Channel}
+	}
+	usdtRate, err := GetUsdtRate(currency)
+	if err != nil {
+		return nil, err
+	}
+	ttl := config.GetQuoteTtl()
+	quote := &response.QuoteResponse{
+		QuoteId:        "qt" + GenerateCode(),
+		MerchantId:     req.MerchantId,
+		Amount:         payAmount,
+		Currency:       currency,
+		Rate:           usdtRate.Rate,
+		RateSource:     usdtRate.Source,
+		RateAt:         usdtRate.UpdatedAt,
+		ExpirationTime: carbon.Now().AddSeconds(int(ttl.Seconds())).Timestamp(),
+	}
+	var priceErr error
+	for _, channel := range channels {
+		price, err := CalculateChannelPrice(req.MerchantId, channel, currency, payAmount, decimal.NewFromFloat(usdtRate.Rate))
+		if errors.Is(err, constant.PricingAmountRangeErr) || errors.Is(err, constant.PayAmountErr) {
+			// 金额不满足该链的定价规则，不提供该链报价
+			priceErr = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		amount := price.Usdt.InexactFloat64()
+		wallets, err := data.GetMerchantAvailableWallet(req.MerchantId, channel)
+		if err != nil {
+			return nil, err
+		}
+		wallets, err = FilterWalletByDailyLimit(amount, wallets)
+		if err != nil {
+			return nil, err
+		}
+		quote.Channels = append(quote.Channels, response.QuoteChannel{
+			Channel:       channel,
+			Asset:         model.AssetUsdt,
+			ActualAmount:  amount,
+			Available:     len(wallets) > 0,
+			PricingRuleId: price.PricingRuleId,
+			MarkupPercent: price.MarkupPercent,
+			FixedFee:      price.FixedFee,
+			Rounding:      price.Rounding,
+		})
+	}
+	if len(quote.Channels) == 0 {
+		return nil, priceErr
+	}
+	content, err := json.Cjson.MarshalToString(quote)
+	if err != nil {
+		return nil, err
+	}
+	if err = data.SaveQuote(quote.QuoteId, content, ttl); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// GetQuote 获取有效期内且未使用的报价
+func GetQuote(quoteId string) (*response.QuoteResponse, error) {
+	content, err := data.GetQuote(quoteId)
+	if err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return nil, constant.QuoteNotExists
+	}
+	quote := new(response.QuoteResponse)
+	if err = json.Cjson.UnmarshalFromString(content, quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// ConsumeQuote 报价只能用于创建一个订单，下单前原子消耗，已被其他请求消耗时视为不存在
+func ConsumeQuote(quoteId string) error {
+	ok, err := data.ConsumeQuote(quoteId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return constant.QuoteNotExists
+	}
+	return nil
+}
+
+// GetQuoteChannelPrice 校验订单与报价一致，并返回报价中该链的定价
+func GetQuoteChannelPrice(quote *response.QuoteResponse, payAmount float64, currency, channel string) (*ChannelPrice, error) {
+	if quote.Amount != payAmount || quote.Currency != currency {
+		return nil, constant.QuoteMismatchErr
+	}
+	for _, quoteChannel := range quote.Channels {
+		if quoteChannel.Channel == channel {
+			return &ChannelPrice{
+				Usdt:          decimal.NewFromFloat(quoteChannel.ActualAmount),
+				PricingRuleId: quoteChannel.PricingRuleId,
+				MarkupPercent: quoteChannel.MarkupPercent,
+				FixedFee:      quoteChannel.FixedFee,
+				Rounding:      quoteChannel.Rounding,
+			}, nil
+		}
+	}
+	return nil, constant.QuoteMismatchErr
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/shopspring/decimal"
+)
+
+func TestGetQuoteChannelPrice(t *testing.T) {
+	quote := &response.QuoteResponse{
+		Amount:   100,
+		Currency: "cny",
+		Channels: []response.QuoteChannel{
+			{Channel: "tron", ActualAmount: 14.08},
+			{Channel: "ethereum", ActualAmount: 14.21, PricingRuleId: 3, MarkupPercent: 1, FixedFee: 0.1, Rounding: "up"},
+		},
+	}
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		channel  string
+		want     *ChannelPrice
+		err      error
+	}{
+		{"without rule", 100, "cny", "tron", &ChannelPrice{Usdt: decimal.NewFromFloat(14.08)}, nil},
+		{"with rule", 100, "cny", "ethereum", &ChannelPrice{Usdt: decimal.NewFromFloat(14.21), PricingRuleId: 3, MarkupPercent: 1, FixedFee: 0.1, Rounding: "up"}, nil},
+		{"amount mismatch", 100.01, "cny", "tron", nil, constant.QuoteMismatchErr},
+		{"currency mismatch", 100, "usd", "tron", nil, constant.QuoteMismatchErr},
+		{"channel not quoted", 100, "cny", "aptos", nil, constant.QuoteMismatchErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetQuoteChannelPrice(quote, tt.amount, tt.currency, tt.channel)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("price = %+v, want nil", got)
+				}
+				return
+			}
+			if !got.Usdt.Equal(tt.want.Usdt) || got.PricingRuleId != tt.want.PricingRuleId || got.MarkupPercent != tt.want.MarkupPercent ||
+				got.FixedFee != tt.want.FixedFee || got.Rounding != tt.want.Rounding {
+				t.Errorf("price = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/util/http_client"
+	"github.com/assimon/luuu/util/json"
+)
+
+const (
+	RateProviderCoinMarketCap = "coinmarketcap"
+	RateProviderCoinGecko     = "coingecko"
+	RateProviderCryptoCompare = "cryptocompare"
+	RateProviderOkx           = "okx"
+	RateProviderKraken        = "kraken"
+	RateProviderFile          = "file"
+
+	CoinMarketCapRateApiUri = "https://api.coinmarketcap.com/data-api/v3/cryptocurrency/detail/chart"
+	CoinGeckoRateApiUri     = "https://api.coingecko.com/api/v3/simple/price"
+	CryptoCompareRateApiUri = "https://min-api.cryptocompare.com/data/price"
+	OkxRateApiUri           = "https://www.okx.com/api/v5/market/exchange-rate"
+	KrakenRateApiUri        = "https://api.kraken.com/0/public/Ticker"
+)
+
+var ErrRateCurrencyNotSupport = errors.New("currency not supported")
+
+// RateProvider 汇率源，返回 1 USDT 可兑换的法币数量
+type RateProvider interface {
+	Name() string
+	FetchRate(currency string) (float64, error)
+}
+
+// GetRateProvider 通过名称获取汇率源
+func GetRateProvider(name string) (RateProvider, error) {
+	switch name {
+	case RateProviderCoinMarketCap:
+		return coinMarketCapProvider{}, nil
+	case RateProviderCoinGecko:
+		return coinGeckoProvider{}, nil
+	case RateProviderCryptoCompare:
+		return cryptoCompareProvider{}, nil
+	case RateProviderOkx:
+		return okxProvider{}, nil
+	case RateProviderKraken:
+		return krakenProvider{}, nil
+	case RateProviderFile:
+		return fileProvider{path: config.GetUsdtRateFile()}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate provider %s", name)
+	}
+}
+
+// getRateJson 请求汇率接口并解析json
+func getRateJson(uri string, params map[string]string, result interface{}) error {
+	client := http_client.GetHttpClient()
+	resp, err := client.R().SetQueryParams(params).SetHeader("Accept", "application/json").Get(uri)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("http status %d", resp.StatusCode())
+	}
+	return json.Cjson.Unmarshal(resp.Body(), result)
+}
+
+// coinMarketCapProvider coinmarketcap 行情图表接口
+type coinMarketCapProvider struct{}
+
+type coinMarketCapRateResp struct {
+	Data struct {
+		Points map[string]struct {
+			C []float64 `json:"c"`
+		} `json:"points"`
+	} `json:"data"`
+	Status struct {
+		ErrorCode    string `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+}
+
+// coinMarketCapConvertIds coinmarketcap 法币id
+var coinMarketCapConvertIds = map[string]string{
+	"cny": "2787",
+	"usd": "2781",
+	"eur": "2790",
+	"hkd": "2792",
+	"jpy": "2797",
+	"twd": "2811",
+}
+
+func (p coinMarketCapProvider) Name() string {
+	return RateProviderCoinMarketCap
+}
+
+func (p coinMarketCapProvider) FetchRate(currency string) (float64, error) {
+	convertId, ok := coinMarketCapConvertIds[currency]
+	if !ok {
+		return 0, ErrRateCurrencyNotSupport
+	}
+	var resp coinMarketCapRateResp
+	err := getRateJson(CoinMarketCapRateApiUri, map[string]string{
+		"id":        "825",
+		"range":     "1H",
+		"convertId": convertId,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Status.ErrorCode != "0" {
+		return 0, errors.New(resp.Status.ErrorMessage)
+	}
+	// 取最新的一个点
+	latest, rate := "", 0.0
+	for timestamp, points := range resp.Data.Points {
+		if len(points.C) > 0 && points.C[0] > 0 && timestamp > latest {
+			latest, rate = timestamp, points.C[0]
+		}
+	}
+	if rate <= 0 {
+		return 0, errors.New("empty points")
+	}
+	return rate, nil
+}
+
+// coinGeckoProvider coingecko 聚合价格
+type coinGeckoProvider struct{}
+
+func (p coinGeckoProvider) Name() string {
+	return RateProviderCoinGecko
+}
+
+func (p coinGeckoProvider) FetchRate(currency string) (float64, error) {
+	var resp map[string]map[string]float64
+	err := getRateJson(CoinGeckoRateApiUri, map[string]string{
+		"ids":           "tether",
+		"vs_currencies": currency,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	rate := resp["tether"][currency]
+	if rate <= 0 {
+		return 0, ErrRateCurrencyNotSupport
+	}
+	return rate, nil
+}
+
+// cryptoCompareProvider cryptocompare 聚合价格
+type cryptoCompareProvider struct{}
+
+func (p cryptoCompareProvider) Name() string {
+	return RateProviderCryptoCompare
+}
+
+func (p cryptoCompareProvider) FetchRate(currency string) (float64, error) {
+	var resp map[string]interface{}
+	symbol := strings.ToUpper(currency)
+	err := getRateJson(CryptoCompareRateApiUri, map[string]string{
+		"fsym":  "USDT",
+		"tsyms": symbol,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	rate, ok := resp[symbol].(float64)
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("unexpected response %v", resp)
+	}
+	return rate, nil
+}
+
+// okxProvider okx 交易所法币汇率，仅支持人民币
+type okxProvider struct{}
+
+type okxRateResp struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		UsdCny string `json:"usdCny"`
+	} `json:"data"`
+}
+
+func (p okxProvider) Name() string {
+	return RateProviderOkx
+}
+
+func (p okxProvider) FetchRate(currency string) (float64, error) {
+	if currency != "cny" {
+		return 0, ErrRateCurrencyNotSupport
+	}
+	var resp okxRateResp
+	if err := getRateJson(OkxRateApiUri, nil, &resp); err != nil {
+		return 0, err
+	}
+	if resp.Code != "0" || len(resp.Data) == 0 {
+		return 0, errors.New(resp.Msg)
+	}
+	return strconv.ParseFloat(resp.Data[0].UsdCny, 64)
+}
+
+// krakenProvider kraken 交易所 USDT 法币交易对
+type krakenProvider struct{}
+
+type krakenRateResp struct {
+	Error  []string `json:"error"`
+	Result map[string]struct {
+		C []string `json:"c"` // 最新成交价, 成交量
+	} `json:"result"`
+}
+
+func (p krakenProvider) Name() string {
+	return RateProviderKraken
+}
+
+func (p krakenProvider) FetchRate(currency string) (float64, error) {
+	switch currency {
+	case "usd", "eur", "gbp", "cad", "aud", "chf", "jpy":
+	default:
+		return 0, ErrRateCurrencyNotSupport
+	}
+	var resp krakenRateResp
+	err := getRateJson(KrakenRateApiUri, map[string]string{
+		"pair": "USDT" + strings.ToUpper(currency),
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Error) > 0 {
+		return 0, errors.New(strings.Join(resp.Error, ","))
+	}
+	for _, ticker := range resp.Result {
+		if len(ticker.C) > 0 {
+			return strconv.ParseFloat(ticker.C[0], 64)
+		}
+	}
+	return 0, errors.New("empty ticker")
+}
+
+// fileProvider 静态文件汇率，文件内容为 {"cny": 7.1, "usd": 1}
+// 文件修改时间超过汇率最大有效期时视为过期
+type fileProvider struct {
+	path string
+}
+
+func (p fileProvider) Name() string {
+	return RateProviderFile
+}
+
+func (p fileProvider) FetchRate(currency string) (float64, error) {
+	if p.path == "" {
+		return 0, errors.New("usdt_rate_file not configured")
+	}
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return 0, err
+	}
+	if time.Since(info.ModTime()) > config.GetUsdtRateMaxStaleness() {
+		return 0, errors.New("rate file is stale")
+	}
+	content, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return 0, err
+	}
+	rates := make(map[string]float64)
+	if err = json.Cjson.Unmarshal(content, &rates); err != nil {
+		return 0, err
+	}
+	rate := rates[currency]
+	if rate <= 0 {
+		return 0, ErrRateCurrencyNotSupport
+	}
+	return rate, nil
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/log"
+	utilMath "github.com/assimon/luuu/util/math"
+	"github.com/assimon/luuu/util/page"
+	"github.com/golang-module/carbon/v2"
+)
+
+const (
+	UsdtRatePrecision = 4         // 汇率保留小数位
+	RateSourceRequest = "request" // 下单请求传入的汇率
+	RateSourceForced  = "forced"  // 强制汇率
+	RateSourceMedian  = "median"  // 汇率源中位数
+
+	usdtRateRefreshLockTtl = 50 * time.Second // 汇率刷新锁有效期，小于刷新间隔，保证每个周期只有一个实例刷新
+)
+
+// RateQuote 单个汇率源报价
+type RateQuote struct {
+	Provider string  `json:"provider"`
+	Rate     float64 `json:"rate"`
+}
+
+// UsdtRate 聚合后的汇率
+type UsdtRate struct {
+	Currency  string          `json:"currency"`
+	Rate      float64         `json:"rate"`
+	Source    string          `json:"source"` // 汇率来源，例如 median:coingecko,okx
+	Quotes    []RateQuote     `json:"quotes"`
+	UpdatedAt carbon.DateTime `json:"updated_at"`
+	Stale     bool            `json:"stale"` // 是否已超过最大有效期
+}
+
+// usdtRateCache 缓存在 redis 中的汇率，更新时间使用时间戳保存，避免序列化时丢失日期
+type usdtRateCache struct {
+	Currency  string      `json:"currency"`
+	Rate      float64     `json:"rate"`
+	Source    string      `json:"source"`
+	Quotes    []RateQuote `json:"quotes"`
+	UpdatedAt int64       `json:"updated_at"`
+}
+
+// RefreshUsdtRates 从所有汇率源拉取各法币汇率并聚合，结果保存到 redis 供所有实例使用
+// 多实例部署时只有获取到刷新锁的实例拉取汇率并记录汇率历史
+func RefreshUsdtRates() {
+	ok, err := data.AcquireUsdtRateRefreshLock(usdtRateRefreshLockTtl)
+	if err != nil {
+		log.Sugar.Error("[rate] ", err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+	var providers []RateProvider
+	for _, name := range config.GetUsdtRateProviders() {
+		provider, err := GetRateProvider(name)
+		if err != nil {
+			log.Sugar.Error("[rate] ", err.Error())
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	for _, currency := range config.GetUsdtRateCurrencies() {
+		quotes := FetchRateQuotes(providers, currency)
+		rate, used, err := AggregateRateQuotes(quotes, config.GetUsdtRateMaxDeviation(), config.GetUsdtRateMinSources())
+		if err != nil {
+			log.Sugar.Errorf("[rate] currency:%s aggregate err:%s, keep last rate", currency, err.Error())
+			continue
+		}
+		var providerNames []string
+		for _, quote := range used {
+			providerNames = append(providerNames, quote.Provider)
+		}
+		usdtRate := UsdtRate{
+			Currency:  currency,
+			Rate:      utilMath.MustParsePrecFloat64(rate, UsdtRatePrecision),
+			Source:    RateSourceMedian + ":" + strings.Join(providerNames, ","),
+			Quotes:    used,
+			UpdatedAt: carbon.DateTime{Carbon: carbon.Now()},
+		}
+		if err = saveUsdtRate(usdtRate); err != nil {
+			log.Sugar.Errorf("[rate] currency:%s save err:%s", currency, err.Error())
+			continue
+		}
+		saveUsdtRateHistory(usdtRate)
+	}
+}
+
+// saveUsdtRate 保存聚合后的汇率
+func saveUsdtRate(rate UsdtRate) error {
+	content, err := json.Cjson.MarshalToString(usdtRateCache{
+		Currency:  rate.Currency,
+		Rate:      rate.Rate,
+		Source:    rate.Source,
+		Quotes:    rate.Quotes,
+		UpdatedAt: rate.UpdatedAt.Timestamp(),
+	})
+	if err != nil {
+		return err
+	}
+	return data.SaveUsdtRate(rate.Currency, content)
+}
+
+// saveUsdtRateHistory 记录汇率历史
+func saveUsdtRateHistory(rate UsdtRate) {
+	quotes, err := json.Cjson.MarshalToString(rate.Quotes)
+	if err != nil {
+		log.Sugar.Error(err)
+		return
+	}
+	err = data.CreateUsdtRateHistory(&mdb.UsdtRateHistory{
+		Currency: rate.Currency,
+		Rate:     rate.Rate,
+		Source:   rate.Source,
+		Quotes:   quotes,
+	})
+	if err != nil {
+		log.Sugar.Error(err)
+	}
+}
+
+// FetchRateQuotes 并发请求各汇率源，失败的汇率源直接跳过
+func FetchRateQuotes(providers []RateProvider, currency string) []RateQuote {
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		quotes []RateQuote
+	)
+	for _, provider := range providers {
+		wg.Add(1)
+		go func(provider RateProvider) {
+			defer wg.Done()
+			rate, err := provider.FetchRate(currency)
+			if err != nil {
+				if !errors.Is(err, ErrRateCurrencyNotSupport) {
+					log.Sugar.Warnf("[rate] provider:%s currency:%s err:%s", provider.Name(), currency, err.Error())
+				}
+				return
+			}
+			if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+				return
+			}
+			lock.Lock()
+			quotes = append(quotes, RateQuote{Provider: provider.Name(), Rate: rate})
+			lock.Unlock()
+		}(provider)
+	}
+	wg.Wait()
+	sort.Slice(quotes, func(i, j int) bool {
+		return quotes[i].Provider < quotes[j].Provider
+	})
+	return quotes
+}
+
+// AggregateRateQuotes 取中位数，剔除偏离中位数超过 maxDeviation% 的报价后再取中位数
+func AggregateRateQuotes(quotes []RateQuote, maxDeviation float64, minSources int) (float64, []RateQuote, error) {
+	if len(quotes) == 0 {
+		return 0, nil, errors.New("no available rate source")
+	}
+	median := medianRate(quotes)
+	var used []RateQuote
+	for _, quote := range quotes {
+		if math.Abs(quote.Rate-median)/median*100 <= maxDeviation {
+			used = append(used, quote)
+		} else {
+			log.Sugar.Warnf("[rate] provider:%s rate:%f rejected, median:%f", quote.Provider, quote.Rate, median)
+		}
+	}
+	if len(used) < minSources {
+		return 0, nil, fmt.Errorf("only %d valid rate sources, require %d", len(used), minSources)
+	}
+	return medianRate(used), used, nil
+}
+
+func medianRate(quotes []RateQuote) float64 {
+	rates := make([]float64, len(quotes))
+	for i, quote := range quotes {
+		rates[i] = quote.Rate
+	}
+	sort.Float64s(rates)
+	middle := len(rates) / 2
+	if len(rates)%2 == 0 {
+		return (rates[middle-1] + rates[middle]) / 2
+	}
+	return rates[middle]
+}
+
+// GetUsdtRate 获取法币汇率，汇率超过最大有效期时返回错误
+func GetUsdtRate(currency string) (*UsdtRate, error) {
+	rate, err := getUsdtRate(currency)
+	if err != nil {
+		return nil, err
+	}
+	if rate.Stale {
+		return nil, constant.RateStaleErr
+	}
+	return rate, nil
+}
+
+// GetCurrentUsdtRates 获取当前汇率，法币为空时返回所有已配置法币
+func GetCurrentUsdtRates(currency string) ([]UsdtRate, error) {
+	currencies := config.GetUsdtRateCurrencies()
+	if currency != "" {
+		currencies = []string{currency}
+	}
+	var rates []UsdtRate
+	for _, item := range currencies {
+		rate, err := getUsdtRate(item)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+	return rates, nil
+}
+
+// GetUsdtRateHistory 分页获取汇率历史
+func GetUsdtRateHistory(req *request.RateHistoryRequest) ([]mdb.UsdtRateHistory, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	histories, total, err := data.GetUsdtRateHistory(strings.ToLower(req.Currency), req.StartTime, req.EndTime, p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return histories, page.GetPagination(p, pageSize, total), nil
+}
+
+// getUsdtRate 获取法币汇率，强制汇率仅对默认法币生效
+func getUsdtRate(currency string) (*UsdtRate, error) {
+	if currency == "" {
+		currency = config.GetUsdtRateDefaultCurrency()
+	}
+	supported := false
+	for _, item := range config.GetUsdtRateCurrencies() {
+		if item == currency {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, constant.CurrencyNotSupportErr
+	}
+	if forced := config.GetForcedUsdtRate(); forced > 0 && currency == config.GetUsdtRateDefaultCurrency() {
+		return &UsdtRate{
+			Currency:  currency,
+			Rate:      forced,
+			Source:    RateSourceForced,
+			UpdatedAt: carbon.DateTime{Carbon: carbon.Now()},
+		}, nil
+	}
+	content, err := data.GetUsdtRate(currency)
+	if err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return &UsdtRate{Currency: currency, Stale: true}, nil
+	}
+	var cache usdtRateCache
+	if err = json.Cjson.UnmarshalFromString(content, &cache); err != nil {
+		return nil, err
+	}
+	rate := UsdtRate{
+		Currency:  cache.Currency,
+		Rate:      cache.Rate,
+		Source:    cache.Source,
+		Quotes:    cache.Quotes,
+		UpdatedAt: carbon.DateTime{Carbon: carbon.CreateFromTimestamp(cache.UpdatedAt)},
+	}
+	rate.Stale = isUsdtRateStale(rate)
+	return &rate, nil
+}
+
+// isUsdtRateStale 汇率无效或更新时间超过最大有效期
+func isUsdtRateStale(rate UsdtRate) bool {
+	return rate.Rate <= 0 || time.Since(rate.UpdatedAt.Carbon2Time()) > config.GetUsdtRateMaxStaleness()
+}
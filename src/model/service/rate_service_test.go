@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/assimon/luuu/util/constant"
+	"github.com/golang-module/carbon/v2"
+)
+
+func TestMedianRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rates []float64
+		want  float64
+	}{
+		{"single", []float64{7.2}, 7.2},
+		{"odd unsorted", []float64{7.3, 7.1, 7.2}, 7.2},
+		{"even", []float64{7.4, 7.1, 7.2, 7.3}, 7.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var quotes []RateQuote
+			for _, rate := range tt.rates {
+				quotes = append(quotes, RateQuote{Rate: rate})
+			}
+			if got := medianRate(quotes); got != tt.want {
+				t.Errorf("medianRate(%v) = %v, want %v", tt.rates, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateRateQuotes(t *testing.T) {
+	tests := []struct {
+		name         string
+		quotes       []RateQuote
+		maxDeviation float64
+		minSources   int
+		want         float64
+		used         []string
+		wantErr      bool
+	}{
+		{
+			name:       "no quotes",
+			minSources: 1,
+			wantErr:    true,
+		},
+		{
+			name:         "single source",
+			quotes:       []RateQuote{{"okx", 7.2}},
+			maxDeviation: 2,
+			minSources:   1,
+			want:         7.2,
+			used:         []string{"okx"},
+		},
+		{
+			name:         "all within deviation",
+			quotes:       []RateQuote{{"binance", 7.1}, {"coingecko", 7.2}, {"okx", 7.3}},
+			maxDeviation: 2,
+			minSources:   1,
+			want:         7.2,
+			used:         []string{"binance", "coingecko", "okx"},
+		},
+		{
+			name:         "outlier rejected",
+			quotes:       []RateQuote{{"binance", 7.1}, {"coingecko", 7.2}, {"file", 9}, {"okx", 7.25}},
+			maxDeviation: 2,
+			minSources:   1,
+			want:         7.2,
+			used:         []string{"binance", "coingecko", "okx"},
+		},
+		{
+			name:         "all rejected",
+			quotes:       []RateQuote{{"coingecko", 7.2}, {"okx", 9}},
+			maxDeviation: 2,
+			minSources:   1,
+			wantErr:      true,
+		},
+		{
+			name:         "not enough sources",
+			quotes:       []RateQuote{{"coingecko", 7.2}, {"okx", 7.21}},
+			maxDeviation: 2,
+			minSources:   3,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, used, err := AggregateRateQuotes(tt.quotes, tt.maxDeviation, tt.minSources)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("rate = %v, want %v", got, tt.want)
+			}
+			var providers []string
+			for _, quote := range used {
+				providers = append(providers, quote.Provider)
+			}
+			if !reflect.DeepEqual(providers, tt.used) {
+				t.Errorf("used = %v, want %v", providers, tt.used)
+			}
+		})
+	}
+}
+
+func TestIsUsdtRateStale(t *testing.T) {
+	tests := []struct {
+		name         string
+		rate         float64
+		age          int // 距上次更新的秒数
+		maxStaleness interface{}
+		want         bool
+	}{
+		{"fresh", 7.2, 10, "", false},
+		{"zero rate", 0, 10, "", true},
+		{"older than default", 7.2, 601, "", true},
+		{"within configured", 7.2, 601, 3600, false},
+		{"older than configured", 7.2, 61, 60, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestConfig(t, map[string]interface{}{"usdt_rate_max_staleness": tt.maxStaleness})
+			rate := UsdtRate{
+				Rate:      tt.rate,
+				UpdatedAt: carbon.DateTime{Carbon: carbon.Now().SubSeconds(tt.age)},
+			}
+			if got := isUsdtRateStale(rate); got != tt.want {
+				t.Errorf("isUsdtRateStale = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUsdtRateForced(t *testing.T) {
+	setTestConfig(t, map[string]interface{}{"usdt_rate_currencies": "cny,usd", "forced_usdt_rate": 7.1})
+	rate, err := GetUsdtRate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.Currency != "cny" || rate.Rate != 7.1 || rate.Source != RateSourceForced || rate.Stale {
+		t.Errorf("GetUsdtRate = %+v, want forced cny rate", rate)
+	}
+	if _, err = GetUsdtRate("eur"); !errors.Is(err, constant.CurrencyNotSupportErr) {
+		t.Errorf("unsupported currency err = %v, want %v", err, constant.CurrencyNotSupportErr)
+	}
+}
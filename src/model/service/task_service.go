@@ -1,7 +1,9 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,21 +13,22 @@ import (
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model"
 	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/model/request"
-	"github.com/assimon/luuu/mq"
-	"github.com/assimon/luuu/mq/handle"
 	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/constant"
 	"github.com/assimon/luuu/util/http_client"
 	"github.com/assimon/luuu/util/json"
 	"github.com/assimon/luuu/util/log"
 	"github.com/golang-module/carbon/v2"
 	"github.com/gookit/goutil/stdutil"
-	"github.com/hibiken/asynq"
 	"github.com/shopspring/decimal"
 )
 
 const UsdtTrc20ApiUri = "https://apilist.tronscanapi.com/api/transfer/trc20"
+const UsdtTrc20Contract = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
 const EtherscanApiUri = "https://api.etherscan.io/v2/api"
+const EvmMinConfirmations = 5 // EVM 转账最少确认数
 
 type UsdtTrc20Resp struct {
 	PageSize int         `json:"page_size"`
@@ -116,7 +119,7 @@ func Trc20ApiScan(token string, wg *sync.WaitGroup) {
 		"start":           "0",
 		"direction":       "2",
 		"db_version":      "1",
-		"trc20Id":         "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		"trc20Id":         UsdtTrc20Contract,
 		"address":         token,
 		"start_timestamp": stdutil.ToString(startTime),
 		"end_timestamp":   stdutil.ToString(endTime),
@@ -162,6 +165,15 @@ func Trc20ApiScan(token string, wg *sync.WaitGroup) {
 			log.Sugar.Warnf("Orders cannot actually be matched: %s <-> %s", tradeId, transfer.Hash)
 			continue
 		}
+		// 转账须匹配订单当前的付款信息或仍在占用期内的原付款信息
+		matched, err := IsOrderPaymentMatched(order, tokenWithChainPrefix, amount)
+		if err != nil {
+			panic(err)
+		}
+		if !matched {
+			log.Sugar.Warnf("Orders cannot actually be matched: %s <-> %s", tradeId, transfer.Hash)
+			continue
+		}
 		// 到这一步就完全算是支付成功了
 		req := &request.OrderProcessingRequest{
 			TokenWithChainPrefix: tokenWithChainPrefix,
@@ -170,75 +182,110 @@ func Trc20ApiScan(token string, wg *sync.WaitGroup) {
 			BlockTransactionId:   transfer.Hash,
 		}
 		err = OrderProcessing(req)
+		if errors.Is(err, constant.OrderNotWaitPayErr) {
+			sendLatePaymentBotMessage(order, tokenWithChainPrefix, transfer.Hash)
+			continue
+		}
 		if err != nil {
 			panic(err)
 		}
 		// 回调队列
-		orderCallbackQueue, _ := handle.NewOrderCallbackQueue(order)
-		mq.MClient.Enqueue(orderCallbackQueue, asynq.MaxRetry(5))
+		if err = EnqueueOrderCallback(order); err != nil {
+			log.Sugar.Error(err)
+		}
 		// 发送机器人消息
-		msgTpl := `
-<b>📢📢有新的 Trc20 交易支付成功！</b>
+		sendPaySuccessBotMessage("有新的 Trc20 交易支付成功！", order, tokenWithChainPrefix, "交易哈希", transfer.Hash)
+	}
+}
+
+// sendPaySuccessBotMessage 发送支付成功机器人消息，附带收款钱包的标签与分组
+func sendPaySuccessBotMessage(title string, order *mdb.Orders, tokenWithChainPrefix, txLabel, txId string) {
+	walletLabel := "-"
+	parts := strings.SplitN(tokenWithChainPrefix, ":", 2)
+	if len(parts) == 2 {
+		wallet, err := data.GetWalletAddressByToken(parts[1], parts[0])
+		if err != nil {
+			log.Sugar.Error(err)
+		}
+		if wallet != nil && wallet.ID > 0 {
+			walletLabel = wallet.DisplayLabel()
+		}
+	}
+	msgTpl := `
+<b>📢📢%s</b>
 <pre>交易号：%s</pre>
 <pre>订单号：%s</pre>
 <pre>请求支付金额：%f cny</pre>
 <pre>实际支付金额：%f usdt</pre>
 <pre>钱包地址：%s</pre>
+<pre>钱包标签：%s</pre>
 <pre>订单创建时间：%s</pre>
 <pre>支付成功时间：%s</pre>
+<pre>%s：%s</pre>
+`
+	msg := fmt.Sprintf(msgTpl,
+		html.EscapeString(title), order.TradeId, html.EscapeString(order.OrderId), order.Amount, order.ActualAmount, tokenWithChainPrefix,
+		html.EscapeString(walletLabel), order.CreatedAt.ToDateTimeString(), carbon.Now().ToDateTimeString(), txLabel, txId)
+	telegram.SendToMerchant(order.MerchantId, msg)
+}
+
+// sendLatePaymentBotMessage 订单已过期或已取消后才匹配到的付款不会改为支付成功，通知人工处理
+func sendLatePaymentBotMessage(order *mdb.Orders, tokenWithChainPrefix, txId string) {
+	log.Sugar.Warnf("[order] late payment trade_id:%s tx:%s", order.TradeId, txId)
+	msgTpl := `
+<b>⚠️订单已过期或已取消后收到付款，未标记为支付成功，请人工处理！</b>
+<pre>交易号：%s</pre>
+<pre>订单号：%s</pre>
+<pre>实际支付金额：%f usdt</pre>
+<pre>钱包地址：%s</pre>
 <pre>交易哈希：%s</pre>
 `
-		msg := fmt.Sprintf(msgTpl,
-			order.TradeId, order.OrderId, order.Amount, order.ActualAmount, tokenWithChainPrefix, order.CreatedAt.ToDateTimeString(), carbon.Now().ToDateTimeString(), transfer.Hash)
-		telegram.SendToBot(msg)
-	}
+	msg := fmt.Sprintf(msgTpl, order.TradeId, html.EscapeString(order.OrderId), order.ActualAmount, tokenWithChainPrefix, txId)
+	telegram.SendToMerchant(order.MerchantId, msg)
 }
 
-func EtherscanApiScan(chainName, token string, wg *sync.WaitGroup) {
-	defer wg.Done()
-	defer func() {
-		if err := recover(); err != nil {
-			fmt.Println("EtherscanCallBack:", time.Now().UTC().Format("2006-01-02 15:04:05 MST"), err)
-			log.Sugar.Error(err)
-		}
-	}()
-	var chainId string
+// getEvmChainParams 获取evm链的 chainid、usdt合约地址与精度
+func getEvmChainParams(chainName string) (chainId string, usdtContract string, decimalDivisor decimal.Decimal, ok bool) {
 	switch chainName {
 	case model.ChainNamePolygonPOS:
 		chainId = "137"
-	case model.ChainNameBSC:
-		chainId = "56"
-	case model.ChainNameAVAXC:
-		chainId = "43114"
-	case model.ChainNameETH:
-		chainId = "1"
-	case model.ChainNameArbitrum:
-		chainId = "42161"
-	default:
-		return
-	}
-	var usdtContract string
-	switch chainName {
-	case model.ChainNamePolygonPOS:
 		usdtContract = "0xc2132d05d31c914a87c6611c10748aeb04b58e8f"
 	case model.ChainNameBSC:
+		chainId = "56"
 		usdtContract = "0x55d398326f99059fF775485246999027B3197955"
 	case model.ChainNameAVAXC:
+		chainId = "43114"
 		usdtContract = "0x9702230a8ea53601f5cd2dc00fdbc13d4df4a8c7"
 	case model.ChainNameETH:
+		chainId = "1"
 		usdtContract = "0xdac17f958d2ee523a2206206994597c13d831ec7"
 	case model.ChainNameArbitrum:
+		chainId = "42161"
 		usdtContract = "0xFd086bC7CD5C481DCC9C85ebE478A1C0b69FCbb9"
 	default:
-		return
+		return "", "", decimal.Zero, false
 	}
-	var decimalDivisor decimal.Decimal
 	switch chainName {
 	case model.ChainNameBSC:
 		decimalDivisor = decimal.NewFromFloat(1000000000000000000) // 18
 	default:
 		decimalDivisor = decimal.NewFromFloat(1000000) // 6
 	}
+	return chainId, usdtContract, decimalDivisor, true
+}
+
+func EtherscanApiScan(chainName, token string, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer func() {
+		if err := recover(); err != nil {
+			fmt.Println("EtherscanCallBack:", time.Now().UTC().Format("2006-01-02 15:04:05 MST"), err)
+			log.Sugar.Error(err)
+		}
+	}()
+	chainId, usdtContract, decimalDivisor, ok := getEvmChainParams(chainName)
+	if !ok {
+		return
+	}
 	tokenWithChainPrefix := chainName + ":" + token
 	if !data.IsWalletLocked(tokenWithChainPrefix) {
 		return
@@ -276,7 +323,7 @@ func EtherscanApiScan(chainName, token string, wg *sync.WaitGroup) {
 		// EVM 地址不区分大小写
 		isUSDT := strings.EqualFold(transfer.ContractAddress, usdtContract)
 		isToThisAccount := strings.EqualFold(transfer.To, token)
-		if !isUSDT || !isToThisAccount || confirmation < 5 {
+		if !isUSDT || !isToThisAccount {
 			// fmt.Println("不符合条件的转账:", transfer)
 			continue
 		}
@@ -306,6 +353,22 @@ func EtherscanApiScan(chainName, token string, wg *sync.WaitGroup) {
 			log.Sugar.Warnf("Orders cannot actually be matched: %s <-> %s", tradeId, transfer.Hash)
 			continue
 		}
+		// 转账须匹配订单当前的付款信息或仍在占用期内的原付款信息
+		matched, err := IsOrderPaymentMatched(order, tokenWithChainPrefix, amount)
+		if err != nil {
+			panic(err)
+		}
+		if !matched {
+			log.Sugar.Warnf("Orders cannot actually be matched: %s <-> %s", tradeId, transfer.Hash)
+			continue
+		}
+		// 已收到转账但确认数不足，通知商户等待区块确认
+		if confirmation < EvmMinConfirmations {
+			if err = EnqueueOrderEvent(order, mdb.OrderEventConfirming); err != nil {
+				log.Sugar.Error(err)
+			}
+			continue
+		}
 		// 到这一步就完全算是支付成功了
 		req := &request.OrderProcessingRequest{
 			TokenWithChainPrefix: tokenWithChainPrefix,
@@ -314,26 +377,18 @@ func EtherscanApiScan(chainName, token string, wg *sync.WaitGroup) {
 			BlockTransactionId:   transfer.Hash,
 		}
 		err = OrderProcessing(req)
+		if errors.Is(err, constant.OrderNotWaitPayErr) {
+			sendLatePaymentBotMessage(order, tokenWithChainPrefix, transfer.Hash)
+			continue
+		}
 		if err != nil {
 			panic(err)
 		}
 		// 回调队列
-		orderCallbackQueue, _ := handle.NewOrderCallbackQueue(order)
-		_, _ = mq.MClient.Enqueue(orderCallbackQueue, asynq.MaxRetry(5))
+		if err = EnqueueOrderCallback(order); err != nil {
+			log.Sugar.Error(err)
+		}
 		// 发送机器人消息
-		msgTpl := `
-<b>📢📢有新的交易支付成功！</b>
-<pre>交易号：%s</pre>
-<pre>订单号：%s</pre>
-<pre>请求支付金额：%f cny</pre>
-<pre>实际支付金额：%f usdt</pre>
-<pre>钱包地址：%s</pre>
-<pre>订单创建时间：%s</pre>
-<pre>支付成功时间：%s</pre>
-<pre>交易哈希：%s</pre>
-`
-		msg := fmt.Sprintf(msgTpl,
-			order.TradeId, order.OrderId, order.Amount, order.ActualAmount, tokenWithChainPrefix, order.CreatedAt.ToDateTimeString(), carbon.Now().ToDateTimeString(), transfer.Hash)
-		telegram.SendToBot(msg)
+		sendPaySuccessBotMessage("有新的交易支付成功！", order, tokenWithChainPrefix, "交易哈希", transfer.Hash)
 	}
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,13 +11,9 @@ import (
 
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/request"
-	"github.com/assimon/luuu/mq"
-	"github.com/assimon/luuu/mq/handle"
-	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/constant"
 	"github.com/assimon/luuu/util/http_client"
 	"github.com/assimon/luuu/util/log"
-	"github.com/golang-module/carbon/v2"
-	"github.com/hibiken/asynq"
 	"github.com/shopspring/decimal"
 )
 
@@ -177,6 +174,16 @@ func AptosApiScan(token string, wg *sync.WaitGroup) {
 				continue
 			}
 
+			// 转账须匹配订单当前的付款信息或仍在占用期内的原付款信息
+			matched, err := IsOrderPaymentMatched(order, tokenWithChainPrefix, amount)
+			if err != nil {
+				panic(err)
+			}
+			if !matched {
+				log.Sugar.Warnf("Orders cannot actually be matched: %s <-> aptos_tx_version:%d", tradeId, tx.TransactionVersion)
+				continue
+			}
+
 			// 调用订单处理（沿用你的 request 结构）
 			req := &request.OrderProcessingRequest{
 				TokenWithChainPrefix: tokenWithChainPrefix,
@@ -186,37 +193,21 @@ func AptosApiScan(token string, wg *sync.WaitGroup) {
 				BlockTransactionId: fmt.Sprintf("%d", tx.TransactionVersion),
 			}
 			err = OrderProcessing(req)
+			if errors.Is(err, constant.OrderNotWaitPayErr) {
+				sendLatePaymentBotMessage(order, tokenWithChainPrefix, req.BlockTransactionId)
+				continue
+			}
 			if err != nil {
 				panic(err)
 			}
 
 			// 回调队列
-			orderCallbackQueue, _ := handle.NewOrderCallbackQueue(order)
-			mq.MClient.Enqueue(orderCallbackQueue, asynq.MaxRetry(5))
-
-			// 发送机器人消息（格式可按需调整）
-			msgTpl := `
-<b>📢📢 有新的 Aptos 交易支付成功！</b>
-<pre>交易号：%s</pre>
-<pre>订单号：%s</pre>
-<pre>请求支付金额：%f cny</pre>
-<pre>实际支付金额：%f token</pre>
-<pre>钱包地址：%s</pre>
-<pre>订单创建时间：%s</pre>
-<pre>支付成功时间：%s</pre>
-<pre>aptos_tx_version: %d</pre>
-`
-			msg := fmt.Sprintf(msgTpl,
-				order.TradeId,
-				order.OrderId,
-				order.Amount,
-				order.ActualAmount,
-				tokenWithChainPrefix,
-				order.CreatedAt.ToDateTimeString(),
-				carbon.Now().ToDateTimeString(),
-				tx.TransactionVersion,
-			)
-			telegram.SendToBot(msg)
+			if err = EnqueueOrderCallback(order); err != nil {
+				log.Sugar.Error(err)
+			}
+
+			// 发送机器人消息
+			sendPaySuccessBotMessage("有新的 Aptos 交易支付成功！", order, tokenWithChainPrefix, "aptos_tx_version", fmt.Sprintf("%d", tx.TransactionVersion))
 		}
 	}
 }
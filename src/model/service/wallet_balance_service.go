@@ -0,0 +1,450 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/telegram"
+	"github.com/assimon/luuu/util/http_client"
+	"github.com/assimon/luuu/util/log"
+	"github.com/gookit/goutil/stdutil"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	Trc20AccountTokensApiUri = "https://apilist.tronscanapi.com/api/account/tokens"
+	AptosNativeCoinType      = "0x1::aptos_coin::AptosCoin"
+	AptosNativeAssetType     = "0xa"
+
+	WalletAlertSweep     = "sweep"     // 余额超过归集阈值
+	WalletAlertReconcile = "reconcile" // 链上收款与订单金额不一致
+
+	// 最近的入账可能还未被扫描任务处理，对账时忽略
+	walletReconcileDelay = 10 * time.Minute
+)
+
+// WalletTransfer 钱包usdt入账记录
+type WalletTransfer struct {
+	Hash   string
+	Amount decimal.Decimal
+}
+
+type trc20AccountTokensResp struct {
+	Data []struct {
+		TokenId      string `json:"tokenId"`
+		Balance      string `json:"balance"`
+		TokenDecimal int32  `json:"tokenDecimal"`
+	} `json:"data"`
+}
+
+type etherscanResultResp struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Result  string `json:"result"`
+}
+
+type aptosBalanceResp struct {
+	Data struct {
+		CurrentFungibleAssetBalances []struct {
+			AssetType string      `json:"asset_type"`
+			Amount    json.Number `json:"amount"`
+		} `json:"current_fungible_asset_balances"`
+	} `json:"data"`
+}
+
+type aptosDepositResp struct {
+	Data struct {
+		FungibleAssetActivities []struct {
+			Amount             json.Number `json:"amount"`
+			TransactionVersion json.Number `json:"transaction_version"`
+		} `json:"fungible_asset_activities"`
+	} `json:"data"`
+}
+
+// CheckWalletBalance 查询钱包余额并保存快照，余额超过归集阈值或链上收款与订单金额不一致时告警
+func CheckWalletBalance(wallet mdb.WalletAddress, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Sugar.Error("CheckWalletBalance:", err)
+		}
+	}()
+	tokenWithChainPrefix := wallet.Channel + ":" + wallet.Token
+	usdtBalance, nativeBalance, err := FetchWalletBalance(wallet.Channel, wallet.Token)
+	if err != nil {
+		log.Sugar.Errorf("[wallet balance] %s fetch balance err: %v", tokenWithChainPrefix, err)
+		return
+	}
+	until := time.Now().Add(-walletReconcileDelay)
+	since := until.Add(-config.GetWalletReconcileDuration())
+	transfers, err := FetchWalletTransfers(wallet.Channel, wallet.Token, since, until)
+	if err != nil {
+		log.Sugar.Errorf("[wallet balance] %s fetch transfers err: %v", tokenWithChainPrefix, err)
+		return
+	}
+	received := decimal.Zero
+	var blockIds []string
+	for _, transfer := range transfers {
+		received = received.Add(transfer.Amount)
+		blockIds = append(blockIds, transfer.Hash)
+	}
+	paid, err := data.GetPaidOrderAmountByBlockIds(tokenWithChainPrefix, blockIds)
+	if err != nil {
+		log.Sugar.Error(err)
+		return
+	}
+	balance := &mdb.WalletBalance{
+		WalletId:             wallet.ID,
+		TokenWithChainPrefix: tokenWithChainPrefix,
+		Channel:              wallet.Channel,
+		UsdtBalance:          usdtBalance.InexactFloat64(),
+		NativeBalance:        nativeBalance.InexactFloat64(),
+		ReceivedAmount:       received.InexactFloat64(),
+		PaidOrderAmount:      paid,
+	}
+	if err = data.CreateWalletBalance(balance); err != nil {
+		log.Sugar.Error(err)
+		return
+	}
+	threshold := config.GetWalletSweepThreshold()
+	if threshold > 0 && usdtBalance.GreaterThan(decimal.NewFromFloat(threshold)) {
+		msgTpl := `
+<b>⚠️钱包余额超过归集阈值，请及时归集！</b>
+<pre>钱包地址：%s</pre>
+<pre>usdt余额：%s</pre>
+<pre>gas余额：%s</pre>
+<pre>归集阈值：%s</pre>
+`
+		sendWalletAlert(WalletAlertSweep, wallet.MerchantId, tokenWithChainPrefix, fmt.Sprintf(msgTpl,
+			tokenWithChainPrefix, usdtBalance.String(), nativeBalance.String(), decimal.NewFromFloat(threshold).String()))
+	}
+	diff := received.Sub(decimal.NewFromFloat(paid))
+	if diff.Abs().GreaterThan(decimal.NewFromFloat(config.GetWalletReconcileTolerance())) {
+		msgTpl := `
+<b>⚠️钱包收款与订单金额不一致！</b>
+<pre>钱包地址：%s</pre>
+<pre>对账区间：%s ~ %s</pre>
+<pre>链上收款：%s usdt (%d 笔)</pre>
+<pre>支付成功订单：%s usdt</pre>
+<pre>差额：%s usdt</pre>
+`
+		sendWalletAlert(WalletAlertReconcile, wallet.MerchantId, tokenWithChainPrefix, fmt.Sprintf(msgTpl,
+			tokenWithChainPrefix, since.Format("2006-01-02 15:04:05"), until.Format("2006-01-02 15:04:05"),
+			received.String(), len(transfers), decimal.NewFromFloat(paid).String(), diff.String()))
+	}
+}
+
+// sendWalletAlert 向管理员与钱包所属商户发送钱包告警，静默期内不重复发送
+func sendWalletAlert(alertType string, merchantId uint64, tokenWithChainPrefix, msg string) {
+	ok, err := data.AcquireWalletAlert(alertType, tokenWithChainPrefix, config.GetWalletAlertSilenceDuration())
+	if err != nil {
+		log.Sugar.Error(err)
+		return
+	}
+	if ok {
+		telegram.SendToMerchant(merchantId, msg)
+	}
+}
+
+// FetchWalletBalance 查询钱包的usdt余额与原生代币余额
+func FetchWalletBalance(channel, token string) (usdtBalance decimal.Decimal, nativeBalance decimal.Decimal, err error) {
+	switch channel {
+	case model.ChainNameTRC20:
+		return fetchTrc20Balance(token)
+	case model.ChainNameAptos:
+		return fetchAptosBalance(token)
+	default:
+		return fetchEvmBalance(channel, token)
+	}
+}
+
+// FetchWalletTransfers 查询钱包在时间区间内的usdt入账
+func FetchWalletTransfers(channel, token string, since, until time.Time) ([]WalletTransfer, error) {
+	switch channel {
+	case model.ChainNameTRC20:
+		return fetchTrc20Transfers(token, since, until)
+	case model.ChainNameAptos:
+		return fetchAptosTransfers(token, since, until)
+	default:
+		return fetchEvmTransfers(channel, token, since, until)
+	}
+}
+
+func fetchTrc20Balance(token string) (decimal.Decimal, decimal.Decimal, error) {
+	usdtBalance, nativeBalance := decimal.Zero, decimal.Zero
+	client := http_client.GetHttpClient()
+	resp, err := client.R().SetQueryParams(map[string]string{
+		"address": token,
+		"start":   "0",
+		"limit":   "50",
+	}).Get(Trc20AccountTokensApiUri)
+	if err != nil {
+		return usdtBalance, nativeBalance, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return usdtBalance, nativeBalance, fmt.Errorf("http status %d", resp.StatusCode())
+	}
+	var tokensResp trc20AccountTokensResp
+	if err = json.Unmarshal(resp.Body(), &tokensResp); err != nil {
+		return usdtBalance, nativeBalance, err
+	}
+	for _, item := range tokensResp.Data {
+		balance, err := decimal.NewFromString(item.Balance)
+		if err != nil {
+			return usdtBalance, nativeBalance, err
+		}
+		balance = balance.Shift(-item.TokenDecimal)
+		switch item.TokenId {
+		case "_":
+			nativeBalance = balance
+		case UsdtTrc20Contract:
+			usdtBalance = balance
+		}
+	}
+	return usdtBalance, nativeBalance, nil
+}
+
+func fetchTrc20Transfers(token string, since, until time.Time) ([]WalletTransfer, error) {
+	client := http_client.GetHttpClient()
+	resp, err := client.R().SetQueryParams(map[string]string{
+		"sort":            "-timestamp",
+		"limit":           "50",
+		"start":           "0",
+		"direction":       "2",
+		"db_version":      "1",
+		"trc20Id":         UsdtTrc20Contract,
+		"address":         token,
+		"start_timestamp": stdutil.ToString(since.UnixNano() / 1e6),
+		"end_timestamp":   stdutil.ToString(until.UnixNano() / 1e6),
+	}).Get(UsdtTrc20ApiUri)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("http status %d", resp.StatusCode())
+	}
+	var trc20Resp UsdtTrc20Resp
+	if err = json.Unmarshal(resp.Body(), &trc20Resp); err != nil {
+		return nil, err
+	}
+	var transfers []WalletTransfer
+	for _, transfer := range trc20Resp.Data {
+		if transfer.To != token || transfer.ContractRet != "SUCCESS" {
+			continue
+		}
+		amount, err := decimal.NewFromString(transfer.Amount)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, WalletTransfer{
+			Hash:   transfer.Hash,
+			Amount: amount.Shift(-6),
+		})
+	}
+	return transfers, nil
+}
+
+// etherscanGet 请求etherscan接口并返回result
+func etherscanGet(chainId string, params map[string]string) ([]byte, error) {
+	client := http_client.GetHttpClient()
+	params["chainid"] = chainId
+	params["apiKey"] = config.GetEtherscanApi()
+	resp, err := client.R().SetQueryParams(params).Get(EtherscanApiUri)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("http status %d", resp.StatusCode())
+	}
+	return resp.Body(), nil
+}
+
+func fetchEvmBalance(channel, token string) (decimal.Decimal, decimal.Decimal, error) {
+	chainId, usdtContract, decimalDivisor, ok := getEvmChainParams(channel)
+	if !ok {
+		return decimal.Zero, decimal.Zero, errors.New("unsupported chain " + channel)
+	}
+	fetch := func(params map[string]string) (decimal.Decimal, error) {
+		body, err := etherscanGet(chainId, params)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		var result etherscanResultResp
+		if err = json.Unmarshal(body, &result); err != nil {
+			return decimal.Zero, err
+		}
+		if result.Status != "1" {
+			return decimal.Zero, errors.New(string(body))
+		}
+		return decimal.NewFromString(result.Result)
+	}
+	usdtBalance, err := fetch(map[string]string{
+		"module":          "account",
+		"action":          "tokenbalance",
+		"contractaddress": usdtContract,
+		"address":         token,
+		"tag":             "latest",
+	})
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	nativeBalance, err := fetch(map[string]string{
+		"module":  "account",
+		"action":  "balance",
+		"address": token,
+		"tag":     "latest",
+	})
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	// evm链原生代币精度均为18位
+	return usdtBalance.Div(decimalDivisor), nativeBalance.Shift(-18), nil
+}
+
+func fetchEvmTransfers(channel, token string, since, until time.Time) ([]WalletTransfer, error) {
+	chainId, usdtContract, decimalDivisor, ok := getEvmChainParams(channel)
+	if !ok {
+		return nil, errors.New("unsupported chain " + channel)
+	}
+	body, err := etherscanGet(chainId, map[string]string{
+		"module":          "account",
+		"action":          "tokentx",
+		"contractaddress": usdtContract,
+		"address":         token,
+		"page":            "1",
+		"offset":          "100",
+		"sort":            "desc",
+	})
+	if err != nil {
+		return nil, err
+	}
+	var etherscanResp EtherscanResp
+	if err = json.Unmarshal(body, &etherscanResp); err != nil {
+		return nil, err
+	}
+	// 无交易记录时 status 为 0
+	if etherscanResp.Status != "1" && len(etherscanResp.Data) > 0 {
+		return nil, errors.New(string(body))
+	}
+	var transfers []WalletTransfer
+	for _, transfer := range etherscanResp.Data {
+		if !strings.EqualFold(transfer.To, token) || !strings.EqualFold(transfer.ContractAddress, usdtContract) {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(transfer.TimeStamp, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if timestamp < since.Unix() || timestamp >= until.Unix() {
+			continue
+		}
+		amount, err := decimal.NewFromString(transfer.Value)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, WalletTransfer{
+			Hash:   transfer.Hash,
+			Amount: amount.Div(decimalDivisor),
+		})
+	}
+	return transfers, nil
+}
+
+// aptosGraphqlQuery 请求aptos索引器
+func aptosGraphqlQuery(query string, variables map[string]interface{}, result interface{}) error {
+	client := http_client.GetHttpClient()
+	bodyBytes, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(bodyBytes).
+		Post(AptosGraphqlUrl)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("http status %d", resp.StatusCode())
+	}
+	return json.Unmarshal(resp.Body(), result)
+}
+
+func fetchAptosBalance(token string) (decimal.Decimal, decimal.Decimal, error) {
+	usdtBalance, nativeBalance := decimal.Zero, decimal.Zero
+	var balanceResp aptosBalanceResp
+	err := aptosGraphqlQuery(`query WalletBalance($address: String, $assets: [String!]) {
+  current_fungible_asset_balances(
+    where: {owner_address: {_eq: $address}, asset_type: {_in: $assets}}
+  ) {
+    asset_type
+    amount
+  }
+}`, map[string]interface{}{
+		"address": token,
+		"assets":  []string{AptosAssetType, AptosNativeCoinType, AptosNativeAssetType},
+	}, &balanceResp)
+	if err != nil {
+		return usdtBalance, nativeBalance, err
+	}
+	for _, item := range balanceResp.Data.CurrentFungibleAssetBalances {
+		amount, err := decimal.NewFromString(item.Amount.String())
+		if err != nil {
+			return usdtBalance, nativeBalance, err
+		}
+		if strings.EqualFold(item.AssetType, AptosAssetType) {
+			usdtBalance = usdtBalance.Add(amount.Shift(-6))
+		} else {
+			// APT 精度为8位，迁移期间可能同时存在 coin 与 fungible asset 两种形式
+			nativeBalance = nativeBalance.Add(amount.Shift(-8))
+		}
+	}
+	return usdtBalance, nativeBalance, nil
+}
+
+func fetchAptosTransfers(token string, since, until time.Time) ([]WalletTransfer, error) {
+	var depositResp aptosDepositResp
+	err := aptosGraphqlQuery(`query WalletDeposits($address: String, $asset: String, $since: timestamp, $until: timestamp) {
+  fungible_asset_activities(
+    where: {owner_address: {_eq: $address}, asset_type: {_eq: $asset}, is_transaction_success: {_eq: true}, type: {_like: "%Deposit%"}, transaction_timestamp: {_gte: $since, _lt: $until}}
+    order_by: {transaction_version: desc}
+    limit: 100
+  ) {
+    amount
+    transaction_version
+  }
+}`, map[string]interface{}{
+		"address": token,
+		"asset":   AptosAssetType,
+		"since":   since.UTC().Format("2006-01-02T15:04:05"),
+		"until":   until.UTC().Format("2006-01-02T15:04:05"),
+	}, &depositResp)
+	if err != nil {
+		return nil, err
+	}
+	var transfers []WalletTransfer
+	for _, activity := range depositResp.Data.FungibleAssetActivities {
+		amount, err := decimal.NewFromString(activity.Amount.String())
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, WalletTransfer{
+			Hash:   activity.TransactionVersion.String(),
+			Amount: amount.Shift(-6),
+		})
+	}
+	return transfers, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	WalletSelectStrategySequential = "sequential"  // 按钱包添加顺序
+	WalletSelectStrategyRoundRobin = "round_robin" // 轮询
+	WalletSelectStrategyLeastUsed  = "least_used"  // 最久未使用优先
+	WalletSelectStrategyWeighted   = "weighted"    // 按权重随机
+	WalletSelectStrategyRandom     = "random"      // 随机
+)
+
+// FilterWalletByDailyLimit 过滤掉当日订单数或收款金额已达上限的钱包
+func FilterWalletByDailyLimit(amount float64, walletAddress []mdb.WalletAddress) ([]mdb.WalletAddress, error) {
+	var limited []string
+	for _, address := range walletAddress {
+		if address.DailyOrderLimit > 0 || address.DailyVolumeLimit > 0 {
+			limited = append(limited, address.Channel+":"+address.Token)
+		}
+	}
+	if len(limited) == 0 {
+		return walletAddress, nil
+	}
+	usages, err := data.GetWalletDailyUsage(limited)
+	if err != nil {
+		return nil, err
+	}
+	return filterWalletByUsage(amount, walletAddress, usages), nil
+}
+
+// filterWalletByUsage 按当日用量过滤钱包，加上本次金额后超出收款上限的钱包同样被过滤
+func filterWalletByUsage(amount float64, walletAddress []mdb.WalletAddress, usages map[string]data.WalletDailyUsage) []mdb.WalletAddress {
+	var available []mdb.WalletAddress
+	for _, address := range walletAddress {
+		usage := usages[address.Channel+":"+address.Token]
+		if address.DailyOrderLimit > 0 && usage.OrderCount >= address.DailyOrderLimit {
+			continue
+		}
+		if address.DailyVolumeLimit > 0 {
+			volume := decimal.NewFromFloat(usage.OrderVolume).Add(decimal.NewFromFloat(amount))
+			if volume.GreaterThan(decimal.NewFromFloat(address.DailyVolumeLimit)) {
+				continue
+			}
+		}
+		available = append(available, address)
+	}
+	return available
+}
+
+// SortWalletByStrategy 按配置的分配策略排列候选钱包，排在前面的钱包优先分配
+func SortWalletByStrategy(channel string, walletAddress []mdb.WalletAddress) ([]mdb.WalletAddress, error) {
+	sorted := make([]mdb.WalletAddress, len(walletAddress))
+	copy(sorted, walletAddress)
+	if len(sorted) <= 1 {
+		return sorted, nil
+	}
+	switch config.GetWalletSelectStrategy() {
+	case WalletSelectStrategyRoundRobin:
+		counter, err := data.IncrWalletRoundRobin(channel)
+		if err != nil {
+			return nil, err
+		}
+		sorted = rotateWallet(sorted, counter)
+	case WalletSelectStrategyLeastUsed:
+		lastUsed, err := data.GetWalletLastUsed(channel)
+		if err != nil {
+			return nil, err
+		}
+		sortWalletByLastUsed(sorted, lastUsed)
+	case WalletSelectStrategyWeighted:
+		// 加权随机排列：每个钱包的排序键为 -ln(u)/weight，权重越大越可能排在前面
+		keys := make(map[uint64]float64, len(sorted))
+		for _, address := range sorted {
+			weight := address.Weight
+			if weight <= 0 {
+				weight = 1
+			}
+			keys[address.ID] = -math.Log(1-rand.Float64()) / float64(weight)
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return keys[sorted[i].ID] < keys[sorted[j].ID]
+		})
+	case WalletSelectStrategyRandom:
+		rand.Shuffle(len(sorted), func(i, j int) {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		})
+	}
+	return sorted, nil
+}
+
+// rotateWallet 第 counter 次轮询从第 (counter-1)%n 个钱包开始
+func rotateWallet(walletAddress []mdb.WalletAddress, counter int64) []mdb.WalletAddress {
+	start := int((counter - 1) % int64(len(walletAddress)))
+	return append(walletAddress[start:], walletAddress[:start]...)
+}
+
+// sortWalletByLastUsed 最久未使用的钱包排在前面，从未使用过的钱包最优先
+func sortWalletByLastUsed(walletAddress []mdb.WalletAddress, lastUsed map[string]int64) {
+	sort.SliceStable(walletAddress, func(i, j int) bool {
+		return lastUsed[walletAddress[i].Token] < lastUsed[walletAddress[j].Token]
+	})
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/spf13/viper"
+)
+
+func testWallets(tokens ...string) []mdb.WalletAddress {
+	var wallets []mdb.WalletAddress
+	for i, token := range tokens {
+		wallet := mdb.WalletAddress{Token: token, Channel: "tron"}
+		wallet.ID = uint64(i + 1)
+		wallets = append(wallets, wallet)
+	}
+	return wallets
+}
+
+func walletTokens(wallets []mdb.WalletAddress) []string {
+	tokens := make([]string, 0, len(wallets))
+	for _, wallet := range wallets {
+		tokens = append(tokens, wallet.Token)
+	}
+	return tokens
+}
+
+func TestFilterWalletByDailyLimitWithoutLimit(t *testing.T) {
+	wallets := testWallets("a", "b")
+	got, err := FilterWalletByDailyLimit(10, wallets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(walletTokens(got), []string{"a", "b"}) {
+		t.Errorf("FilterWalletByDailyLimit = %v, want [a b]", walletTokens(got))
+	}
+}
+
+func TestFilterWalletByUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      float64
+		orderLimit  int
+		volumeLimit float64
+		usage       data.WalletDailyUsage
+		available   bool
+	}{
+		{"no limit", 100, 0, 0, data.WalletDailyUsage{OrderCount: 99, OrderVolume: 9999}, true},
+		{"below order limit", 10, 3, 0, data.WalletDailyUsage{OrderCount: 2}, true},
+		{"order limit reached", 10, 3, 0, data.WalletDailyUsage{OrderCount: 3}, false},
+		{"volume equals limit", 10, 0, 100, data.WalletDailyUsage{OrderVolume: 90}, true},
+		{"volume exceeds limit", 10.01, 0, 100, data.WalletDailyUsage{OrderVolume: 90}, false},
+		{"decimal volume", 0.2, 0, 0.3, data.WalletDailyUsage{OrderVolume: 0.1}, true},
+		{"no usage today", 50, 1, 50, data.WalletDailyUsage{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallets := testWallets("a")
+			wallets[0].DailyOrderLimit = tt.orderLimit
+			wallets[0].DailyVolumeLimit = tt.volumeLimit
+			usages := map[string]data.WalletDailyUsage{"tron:a": tt.usage}
+			got := filterWalletByUsage(tt.amount, wallets, usages)
+			if available := len(got) == 1; available != tt.available {
+				t.Errorf("available = %v, want %v", available, tt.available)
+			}
+		})
+	}
+}
+
+func TestRotateWallet(t *testing.T) {
+	tests := []struct {
+		counter int64
+		want    []string
+	}{
+		{1, []string{"a", "b", "c"}},
+		{2, []string{"b", "c", "a"}},
+		{3, []string{"c", "a", "b"}},
+		{4, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		got := walletTokens(rotateWallet(testWallets("a", "b", "c"), tt.counter))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rotateWallet(%d) = %v, want %v", tt.counter, got, tt.want)
+		}
+	}
+}
+
+func TestSortWalletByLastUsed(t *testing.T) {
+	wallets := testWallets("a", "b", "c", "d")
+	sortWalletByLastUsed(wallets, map[string]int64{"a": 300, "b": 100, "d": 100})
+	want := []string{"c", "b", "d", "a"}
+	if got := walletTokens(wallets); !reflect.DeepEqual(got, want) {
+		t.Errorf("sortWalletByLastUsed = %v, want %v", got, want)
+	}
+}
+
+func TestSortWalletByStrategy(t *testing.T) {
+	defer viper.Set("wallet_select_strategy", "")
+	tests := []struct {
+		strategy string
+		ordered  bool // 是否保持添加顺序
+	}{
+		{"", true},
+		{WalletSelectStrategySequential, true},
+		{WalletSelectStrategyRandom, false},
+		{WalletSelectStrategyWeighted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			viper.Set("wallet_select_strategy", tt.strategy)
+			wallets := testWallets("a", "b", "c", "d", "e")
+			got, err := SortWalletByStrategy("tron", wallets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tokens := walletTokens(got)
+			if tt.ordered && !reflect.DeepEqual(tokens, []string{"a", "b", "c", "d", "e"}) {
+				t.Errorf("order = %v, want added order", tokens)
+			}
+			sort.Strings(tokens)
+			if !reflect.DeepEqual(tokens, []string{"a", "b", "c", "d", "e"}) {
+				t.Errorf("wallets = %v, want all wallets once", tokens)
+			}
+			if !reflect.DeepEqual(walletTokens(wallets), []string{"a", "b", "c", "d", "e"}) {
+				t.Error("input must not be modified")
+			}
+		})
+	}
+}
+
+func TestSortWalletByStrategyWeighted(t *testing.T) {
+	defer viper.Set("wallet_select_strategy", "")
+	viper.Set("wallet_select_strategy", WalletSelectStrategyWeighted)
+	wallets := testWallets("light", "heavy")
+	wallets[0].Weight = 1
+	wallets[1].Weight = 1000
+	heavyFirst := 0
+	for i := 0; i < 200; i++ {
+		got, err := SortWalletByStrategy("tron", wallets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0].Token == "heavy" {
+			heavyFirst++
+		}
+	}
+	// 权重 1000:1 时重钱包排在首位的概率约为 99.9%
+	if heavyFirst < 190 {
+		t.Errorf("heavy wallet first %d/200 times", heavyFirst)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"strconv"
+
+	"github.com/assimon/luuu/model"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/request"
+	"github.com/assimon/luuu/util/constant"
+	"github.com/assimon/luuu/util/page"
+	"github.com/shopspring/decimal"
+)
+
+// GetLatestWalletBalances 获取商户所有钱包最新的余额快照
+func GetLatestWalletBalances(merchantId uint64) ([]mdb.WalletBalance, error) {
+	return data.GetMerchantLatestWalletBalances(merchantId)
+}
+
+// GetWalletBalanceHistory 分页获取钱包余额历史
+func GetWalletBalanceHistory(req *request.WalletBalanceHistoryRequest) ([]mdb.WalletBalance, page.Pagination, error) {
+	wallet, err := data.GetWalletAddressById(req.WalletId)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	if wallet.ID <= 0 || wallet.MerchantId != req.MerchantId {
+		return nil, page.Pagination{}, constant.WalletAddressNotExists
+	}
+	p, pageSize := req.GetPageAndSize()
+	balances, total, err := data.GetWalletBalanceHistory(req.WalletId, req.StartTime, req.EndTime, p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return balances, page.GetPagination(p, pageSize, total), nil
+}
+
+// AddWalletAddress 为商户添加钱包
+func AddWalletAddress(req *request.WalletAddRequest) (*mdb.WalletAddress, error) {
+	if !model.IsChainSupported(req.Channel) {
+		return nil, constant.ChannelNotSupportErr
+	}
+	return data.AddWalletAddress(req.MerchantId, req.Token, req.Channel)
+}
+
+// GetWalletAddressList 按条件分页获取钱包
+func GetWalletAddressList(req *request.WalletListRequest) ([]mdb.WalletAddress, page.Pagination, error) {
+	p, pageSize := req.GetPageAndSize()
+	wallets, total, err := data.GetWalletAddressList(req.MerchantId, req.Channel, req.Status, req.Label, req.GroupName, p, pageSize)
+	if err != nil {
+		return nil, page.Pagination{}, err
+	}
+	return wallets, page.GetPagination(p, pageSize, total), nil
+}
+
+// UpdateWalletAddressInfo 修改钱包信息
+func UpdateWalletAddressInfo(req *request.WalletUpdateRequest) (*mdb.WalletAddress, error) {
+	wallet, err := data.GetWalletAddressById(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.ID <= 0 || wallet.MerchantId != req.MerchantId {
+		return nil, constant.WalletAddressNotExists
+	}
+	info := make(map[string]string)
+	if req.Label != nil {
+		info["label"] = *req.Label
+	}
+	if req.Note != nil {
+		info["note"] = *req.Note
+	}
+	if req.GroupName != nil {
+		info["group_name"] = *req.GroupName
+	}
+	if req.CheckoutMessage != nil {
+		info["checkout_message"] = *req.CheckoutMessage
+	}
+	if req.Weight != nil {
+		info["weight"] = strconv.Itoa(*req.Weight)
+	}
+	if req.DailyVolumeLimit != nil {
+		info["daily_volume_limit"] = decimal.NewFromFloat(*req.DailyVolumeLimit).String()
+	}
+	if req.DailyOrderLimit != nil {
+		info["daily_order_limit"] = strconv.Itoa(*req.DailyOrderLimit)
+	}
+	if err = data.UpdateWalletAddressInfo(wallet.ID, info); err != nil {
+		return nil, err
+	}
+	return data.GetWalletAddressById(wallet.ID)
+}
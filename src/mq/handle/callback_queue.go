@@ -2,12 +2,18 @@ package handle
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/assimon/luuu/config"
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/model/response"
+	"github.com/assimon/luuu/telegram"
 	"github.com/assimon/luuu/util/http_client"
 	"github.com/assimon/luuu/util/json"
 	"github.com/assimon/luuu/util/log"
@@ -17,17 +23,73 @@ import (
 
 const QueueOrderCallback = "order:callback"
 
+// OrderCallbackPayload 回调任务，EventType 为空时按订单当前状态回调最终事件
+type OrderCallbackPayload struct {
+	mdb.Orders
+	EventType string `json:"event_type"`
+}
+
+// EnqueueOrderEvent 投递订单事件回调，由服务层注入以避免循环引用
+var EnqueueOrderEvent func(order *mdb.Orders, event string) error
+
 func NewOrderCallbackQueue(order *mdb.Orders) (*asynq.Task, error) {
-	payload, err := json.Cjson.Marshal(order)
+	return NewOrderEventQueue(order, "")
+}
+
+// NewOrderEventQueue 订单事件回调任务
+func NewOrderEventQueue(order *mdb.Orders, event string) (*asynq.Task, error) {
+	payload, err := json.Cjson.Marshal(OrderCallbackPayload{
+		Orders:    *order,
+		EventType: event,
+	})
 	if err != nil {
 		return nil, err
 	}
 	return asynq.NewTask(QueueOrderCallback, payload), nil
 }
 
+// OrderCallbackTaskId 回调任务id，同一订单同时只存在一个回调任务
+func OrderCallbackTaskId(tradeId string) string {
+	return QueueOrderCallback + ":" + tradeId
+}
+
+// OrderEventTaskId 非最终事件的回调任务id
+func OrderEventTaskId(tradeId, event string) string {
+	return OrderCallbackTaskId(tradeId) + ":" + event
+}
+
+// OrderEventId 事件id，同一订单的同一事件不变，商户可据此幂等处理
+func OrderEventId(tradeId, event string) string {
+	return "evt_" + tradeId + "_" + strings.TrimPrefix(event, "order.")
+}
+
+// OrderCallbackRetryDelay 按回调重试间隔配置计算下次重试时间
+// 任务内的订单为入队时的快照，已回调次数加上本任务的重试次数即为本次失败的回调序号
+func OrderCallbackRetryDelay(n int, t *asynq.Task) time.Duration {
+	var payload OrderCallbackPayload
+	_ = json.Cjson.Unmarshal(t.Payload(), &payload)
+	schedule := config.GetCallbackRetrySchedule()
+	index := n
+	if payload.EventType == "" {
+		index += payload.CallbackNum
+	}
+	if index >= len(schedule) {
+		index = len(schedule) - 1
+	}
+	return schedule[index]
+}
+
+// RetryDelayFunc 队列任务重试间隔
+func RetryDelayFunc(n int, e error, t *asynq.Task) time.Duration {
+	if t.Type() == QueueOrderCallback {
+		return OrderCallbackRetryDelay(n, t)
+	}
+	return asynq.DefaultRetryDelayFunc(n, e, t)
+}
+
 func OrderCallbackHandle(ctx context.Context, t *asynq.Task) error {
-	var order mdb.Orders
-	err := json.Cjson.Unmarshal(t.Payload(), &order)
+	var payload OrderCallbackPayload
+	err := json.Cjson.Unmarshal(t.Payload(), &payload)
 	if err != nil {
 		return err
 	}
@@ -36,9 +98,82 @@ func OrderCallbackHandle(ctx context.Context, t *asynq.Task) error {
 			log.Sugar.Error(err)
 		}
 	}()
-	defer func() {
-		data.SaveCallBackOrdersResp(&order)
-	}()
+	// 以数据库中的订单为准，重试及重新投递时可获取最新的回调状态
+	order, err := data.GetOrderInfoByTradeId(payload.TradeId)
+	if err != nil {
+		return err
+	}
+	if order.ID <= 0 {
+		return nil
+	}
+	if payload.EventType == mdb.OrderEventConfirming {
+		return orderConfirmingCallback(ctx, order)
+	}
+	event := order.CallbackEvent()
+	if event == "" || order.CallBackConfirm == mdb.CallBackConfirmOk || order.CallBackConfirm == mdb.CallBackConfirmDead {
+		return nil
+	}
+	callbackLog := &mdb.CallbackLog{
+		MerchantId: order.MerchantId,
+		TradeId:    order.TradeId,
+		OrderId:    order.OrderId,
+		EventType:  event,
+		Attempt:    order.CallbackNum + 1,
+		NotifyUrl:  order.NotifyUrl,
+		Status:     mdb.CallbackLogStatusFailed,
+	}
+	err = sendOrderCallback(order, event, callbackLog)
+	if err == nil {
+		order.CallBackConfirm = mdb.CallBackConfirmOk
+		callbackLog.Status = mdb.CallbackLogStatusSuccess
+	} else {
+		callbackLog.Error = err.Error()
+		order.CallBackConfirm = mdb.CallBackConfirmNo
+		if callbackLog.Attempt >= config.GetCallbackMaxAttempts() {
+			// 重试全部失败，不再自动回调
+			order.CallBackConfirm = mdb.CallBackConfirmDead
+			sendCallbackDeadBotMessage(order, callbackLog)
+			err = fmt.Errorf("%s: %w", err.Error(), asynq.SkipRetry)
+		}
+	}
+	if saveErr := data.SaveCallBackOrdersResp(order); saveErr != nil {
+		log.Sugar.Error(saveErr)
+	}
+	if logErr := data.CreateCallbackLog(callbackLog); logErr != nil {
+		log.Sugar.Error(logErr)
+	}
+	return err
+}
+
+// orderConfirmingCallback 等待区块确认事件回调，订单已不是待支付时不再发送，不影响订单的回调次数与状态
+func orderConfirmingCallback(ctx context.Context, order *mdb.Orders) error {
+	if order.Status != mdb.StatusWaitPay || !order.IsNotifyEventEnabled(mdb.OrderEventConfirming) {
+		return nil
+	}
+	retried, _ := asynq.GetRetryCount(ctx)
+	callbackLog := &mdb.CallbackLog{
+		MerchantId: order.MerchantId,
+		TradeId:    order.TradeId,
+		OrderId:    order.OrderId,
+		EventType:  mdb.OrderEventConfirming,
+		Attempt:    retried + 1,
+		NotifyUrl:  order.NotifyUrl,
+		Status:     mdb.CallbackLogStatusFailed,
+	}
+	err := sendOrderCallback(order, mdb.OrderEventConfirming, callbackLog)
+	if err == nil {
+		callbackLog.Status = mdb.CallbackLogStatusSuccess
+	} else {
+		callbackLog.Error = err.Error()
+	}
+	if logErr := data.CreateCallbackLog(callbackLog); logErr != nil {
+		log.Sugar.Error(logErr)
+	}
+	return err
+}
+
+// sendOrderCallback 发送回调请求，并将请求与响应记录到回调投递记录
+func sendOrderCallback(order *mdb.Orders, event string, callbackLog *mdb.CallbackLog) error {
 	client := http_client.GetHttpClient()
 	orderResp := response.OrderNotifyResponse{
 		TradeId:            order.TradeId,
@@ -47,22 +182,174 @@ func OrderCallbackHandle(ctx context.Context, t *asynq.Task) error {
 		ActualAmount:       order.ActualAmount,
 		Token:              order.TokenWithChainPrefix,
 		BlockTransactionId: order.BlockTransactionId,
-		Status:             mdb.StatusPaySuccess,
+		Status:             order.Status,
+		EventType:          event,
+		EventId:            OrderEventId(order.TradeId, event),
+		CustomerEmail:      order.CustomerEmail,
+		CustomerName:       order.CustomerName,
+		Metadata:           order.GetMetadata(),
+	}
+	if event == mdb.OrderEventPaid {
+		ledger, err := data.GetFeeLedgerByTradeId(order.TradeId)
+		if err != nil {
+			return err
+		}
+		if ledger.ID > 0 {
+			orderResp.FeeAmount = &ledger.FeeAmount
+			orderResp.NetAmount = &ledger.NetAmount
+		}
 	}
-	signature, err := sign.Get(orderResp, config.GetApiAuthToken())
+	keyId, secret, err := orderCallbackKey(order)
 	if err != nil {
 		return err
 	}
-	orderResp.Signature = signature
-	resp, err := client.R().SetHeader("powered-by", "Epusdt(https://github.com/assimon/epusdt)").SetBody(orderResp).Post(order.NotifyUrl)
+	signMode := config.GetApiSignMode()
+	if signMode != config.ApiSignModeHmac {
+		signature, err := sign.Get(orderResp, secret)
+		if err != nil {
+			return err
+		}
+		orderResp.Signature = signature
+		callbackLog.Signature = signature
+	}
+	var signV2 *sign.V2
+	if signMode != config.ApiSignModeMd5 {
+		signV2, err = sign.GetV2(http.MethodPost, callbackPath(order.NotifyUrl), orderResp, secret)
+		if err != nil {
+			return err
+		}
+		callbackLog.Signature = signV2.Signature
+	}
+	contentType := "application/json"
+	var payload []byte
+	if config.GetCallbackContentType() == config.CallbackContentTypeForm {
+		contentType = "application/x-www-form-urlencoded"
+		payload, err = encodeCallbackForm(orderResp)
+	} else {
+		payload, err = json.Cjson.Marshal(orderResp)
+	}
 	if err != nil {
 		return err
 	}
+	callbackLog.Payload = string(payload)
+	req := client.R()
+	for key, value := range config.GetCallbackHeaders() {
+		req.SetHeader(key, value)
+	}
+	if signV2 != nil {
+		req.SetHeader(sign.HeaderTimestamp, signV2.Timestamp).
+			SetHeader(sign.HeaderNonce, signV2.Nonce).
+			SetHeader(sign.HeaderSignature, signV2.Signature)
+	}
+	if keyId != "" {
+		req.SetHeader(sign.HeaderKeyId, keyId)
+	}
+	startAt := time.Now()
+	resp, err := req.
+		SetHeader("powered-by", "Epusdt(https://github.com/assimon/epusdt)").
+		SetHeader("Content-Type", contentType).
+		SetHeader("Idempotency-Key", orderResp.EventId).
+		SetBody(payload).
+		Post(order.NotifyUrl)
+	callbackLog.Duration = time.Since(startAt).Milliseconds()
+	if err != nil {
+		return err
+	}
+	callbackLog.StatusCode = resp.StatusCode()
 	body := string(resp.Body())
-	if body != "ok" {
-		order.CallBackConfirm = mdb.CallBackConfirmNo
-		return errors.New("not ok")
+	callbackLog.ResponseBody = truncateCallbackBody(body)
+	if !isCallbackAcknowledged(resp.StatusCode(), body) {
+		return fmt.Errorf("not acknowledged, status code: %d", resp.StatusCode())
 	}
-	order.CallBackConfirm = mdb.CallBackConfirmOk
 	return nil
 }
+
+// orderCallbackKey 回调签名密钥，使用创建订单的密钥，密钥已删除或订单未记录密钥时使用 api_auth_token，密钥id为空
+// 密钥停用或过期后仍用于签名该密钥所建订单的回调，商户轮换密钥期间可按请求头中的密钥id选择验签密钥
+func orderCallbackKey(order *mdb.Orders) (string, string, error) {
+	if order.ApiKeyId == "" {
+		return "", config.GetApiAuthToken(), nil
+	}
+	apiKey, err := data.GetApiKeyByKeyId(order.ApiKeyId)
+	if err != nil {
+		return "", "", err
+	}
+	if apiKey.ID <= 0 {
+		return "", config.GetApiAuthToken(), nil
+	}
+	return apiKey.KeyId, apiKey.Secret, nil
+}
+
+// isCallbackAcknowledged 按配置的判断方式检查商户是否已确认回调
+func isCallbackAcknowledged(statusCode int, body string) bool {
+	switch config.GetCallbackSuccessMode() {
+	case config.CallbackSuccessMode2xx:
+		return statusCode >= 200 && statusCode < 300
+	case config.CallbackSuccessModeRegex:
+		re := config.GetCallbackSuccessRegex()
+		return re != nil && re.MatchString(body)
+	case config.CallbackSuccessModeJsonCode:
+		var result map[string]interface{}
+		if err := json.Cjson.UnmarshalFromString(body, &result); err != nil {
+			return false
+		}
+		value, ok := result[config.GetCallbackSuccessJsonKey()]
+		return ok && value != nil && fmt.Sprint(value) == config.GetCallbackSuccessJsonValue()
+	}
+	// 兼容 "ok"、OK\n 等常见写法
+	return strings.EqualFold(strings.Trim(strings.TrimSpace(body), `"'`), "ok")
+}
+
+// callbackPath 回调地址的路径，参与v2签名，解析失败时为 /
+func callbackPath(notifyUrl string) string {
+	u, err := url.Parse(notifyUrl)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// encodeCallbackForm 将回调内容编码为表单，嵌套字段与数值格式与签名一致
+func encodeCallbackForm(orderResp response.OrderNotifyResponse) ([]byte, error) {
+	var params map[string]interface{}
+	content, err := json.Cjson.Marshal(orderResp)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.CjsonNumber.Unmarshal(content, &params); err != nil {
+		return nil, err
+	}
+	flattened, err := sign.FlattenParams(params)
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	for key, value := range flattened {
+		values.Set(key, value)
+	}
+	return []byte(values.Encode()), nil
+}
+
+// sendCallbackDeadBotMessage 回调重试全部失败时通知机器人
+func sendCallbackDeadBotMessage(order *mdb.Orders, callbackLog *mdb.CallbackLog) {
+	msgTpl := `
+<b>⚠️订单回调重试全部失败，已停止自动回调！</b>
+<pre>交易号：%s</pre>
+<pre>订单号：%s</pre>
+<pre>回调地址：%s</pre>
+<pre>回调次数：%d</pre>
+<pre>最后错误：%s</pre>
+`
+	msg := fmt.Sprintf(msgTpl, order.TradeId, html.EscapeString(order.OrderId), html.EscapeString(order.NotifyUrl),
+		callbackLog.Attempt, html.EscapeString(callbackLog.Error))
+	telegram.SendToMerchant(order.MerchantId, msg)
+}
+
+// truncateCallbackBody 截断过长的响应内容
+func truncateCallbackBody(body string) string {
+	runes := []rune(body)
+	if len(runes) <= mdb.CallbackLogBodyMaxLength {
+		return body
+	}
+	return string(runes[:mdb.CallbackLogBodyMaxLength]) + "...(truncated)"
+}
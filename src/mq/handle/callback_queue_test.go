@@ -0,0 +1,100 @@
+package handle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/spf13/viper"
+)
+
+func TestOrderCallbackRetryDelay(t *testing.T) {
+	defer viper.Set("callback_retry_schedule", "")
+	tests := []struct {
+		name        string
+		schedule    string
+		event       string
+		callbackNum int
+		n           int
+		want        time.Duration
+	}{
+		{"first retry", "1m,5m,30m", "", 0, 0, time.Minute},
+		{"continues after previous callbacks", "1m,5m,30m", "", 1, 1, 30 * time.Minute},
+		{"last interval repeats", "1m,5m,30m", "", 2, 5, 30 * time.Minute},
+		{"event ignores callback count", "1m,5m,30m", mdb.OrderEventConfirming, 3, 1, 5 * time.Minute},
+		{"invalid items skipped", "1m,bogus,-5m,10m", "", 0, 1, 10 * time.Minute},
+		{"default schedule", "", "", 0, 2, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("callback_retry_schedule", tt.schedule)
+			order := &mdb.Orders{TradeId: "T1", CallbackNum: tt.callbackNum}
+			task, err := NewOrderEventQueue(order, tt.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := OrderCallbackRetryDelay(tt.n, task); got != tt.want {
+				t.Errorf("OrderCallbackRetryDelay(%d) = %s, want %s", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCallbackAcknowledged(t *testing.T) {
+	defer func() {
+		for _, key := range []string{"callback_success_mode", "callback_success_regex", "callback_success_json_key", "callback_success_json_value"} {
+			viper.Set(key, "")
+		}
+	}()
+	tests := []struct {
+		name       string
+		mode       string
+		regex      string
+		jsonKey    string
+		jsonValue  string
+		statusCode int
+		body       string
+		want       bool
+	}{
+		{"ok", "", "", "", "", 200, "ok", true},
+		{"ok quoted with newline", "ok", "", "", "", 200, "\"OK\"\n", true},
+		{"ok other body", "ok", "", "", "", 200, "success", false},
+		{"2xx", "2xx", "", "", "", 204, "", true},
+		{"2xx redirect", "2xx", "", "", "", 302, "ok", false},
+		{"regex match", "regex", `^\s*success\s*$`, "", "", 200, " success\n", true},
+		{"regex no match", "regex", `^success$`, "", "", 200, "fail", false},
+		{"json code number", "json_code", "", "code", "0", 200, `{"code":0,"msg":"ok"}`, true},
+		{"json code string", "json_code", "", "status", "SUCCESS", 200, `{"status":"SUCCESS"}`, true},
+		{"json code mismatch", "json_code", "", "code", "0", 200, `{"code":1}`, false},
+		{"json code missing", "json_code", "", "code", "0", 200, `{"msg":"ok"}`, false},
+		{"json invalid", "json_code", "", "code", "0", 200, `ok`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("callback_success_mode", tt.mode)
+			viper.Set("callback_success_regex", tt.regex)
+			viper.Set("callback_success_json_key", tt.jsonKey)
+			viper.Set("callback_success_json_value", tt.jsonValue)
+			if err := config.LoadCallbackSuccessRegex(); err != nil {
+				t.Fatal(err)
+			}
+			if got := isCallbackAcknowledged(tt.statusCode, tt.body); got != tt.want {
+				t.Errorf("isCallbackAcknowledged(%d, %q) = %v, want %v", tt.statusCode, tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCallbackSuccessRegexInvalid(t *testing.T) {
+	defer func() {
+		viper.Set("callback_success_mode", "")
+		viper.Set("callback_success_regex", "")
+		_ = config.LoadCallbackSuccessRegex()
+	}()
+	viper.Set("callback_success_mode", "regex")
+	viper.Set("callback_success_regex", "(")
+	if err := config.LoadCallbackSuccessRegex(); err == nil {
+		t.Error("invalid regex must fail to load")
+	}
+}
@@ -5,32 +5,60 @@ import (
 
 	"github.com/assimon/luuu/model/data"
 	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/util/json"
+	"github.com/assimon/luuu/util/log"
 	"github.com/hibiken/asynq"
 )
 
 const QueueOrderExpiration = "order:expiration"
 
-func NewOrderExpirationQueue(tradeId string) (*asynq.Task, error) {
-	return asynq.NewTask(QueueOrderExpiration, []byte(tradeId)), nil
+// OrderExpirationPayload 订单过期任务，订单过期时间晚于 ExpiredAt 时说明已切换付款网络，任务作废
+type OrderExpirationPayload struct {
+	TradeId   string `json:"trade_id"`
+	ExpiredAt int64  `json:"expired_at"`
+}
+
+func NewOrderExpirationQueue(tradeId string, expiredAt int64) (*asynq.Task, error) {
+	payload, err := json.Cjson.Marshal(OrderExpirationPayload{
+		TradeId:   tradeId,
+		ExpiredAt: expiredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(QueueOrderExpiration, payload), nil
 }
 
 // OrderExpirationHandle 设置订单过期
 func OrderExpirationHandle(ctx context.Context, t *asynq.Task) error {
-	tradeId := string(t.Payload())
-	orderInfo, err := data.GetOrderInfoByTradeId(tradeId)
+	var payload OrderExpirationPayload
+	if err := json.Cjson.Unmarshal(t.Payload(), &payload); err != nil {
+		// 兼容旧任务，载荷仅为交易号
+		payload.TradeId = string(t.Payload())
+	}
+	orderInfo, err := data.GetOrderInfoByTradeId(payload.TradeId)
 	if err != nil {
 		return err
 	}
 	if orderInfo.ID <= 0 || orderInfo.Status != mdb.StatusWaitPay {
 		return nil
 	}
-	err = data.UpdateOrderIsExpirationById(orderInfo.ID)
-	if err != nil {
-		return err
+	if payload.ExpiredAt > 0 && !orderInfo.ExpiredAt.IsZero() && orderInfo.ExpiredAt.Timestamp() > payload.ExpiredAt {
+		return nil
 	}
-	err = data.UnLockTransaction(orderInfo.TokenWithChainPrefix, orderInfo.ActualAmount)
+	// 读取订单后可能已支付或切换了付款网络，仅在状态与钱包未变化时设置过期
+	ok, err := data.UpdateOrderIsExpirationById(orderInfo.ID, orderInfo.TokenWithChainPrefix)
 	if err != nil {
 		return err
 	}
-	return nil
+	if !ok {
+		return nil
+	}
+	orderInfo.Status = mdb.StatusExpired
+	if EnqueueOrderEvent != nil {
+		if err = EnqueueOrderEvent(orderInfo, mdb.OrderEventExpired); err != nil {
+			log.Sugar.Error(err)
+		}
+	}
+	return data.UnLockOrderTransactions(orderInfo)
 }
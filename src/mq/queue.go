@@ -10,6 +10,8 @@ import (
 
 var MClient *asynq.Client
 
+var MInspector *asynq.Inspector
+
 func Start() {
 	redis := asynq.RedisClientOpt{
 		Addr: fmt.Sprintf(
@@ -25,6 +27,7 @@ func Start() {
 
 func initClient(redis asynq.RedisClientOpt) {
 	MClient = asynq.NewClient(redis)
+	MInspector = asynq.NewInspector(redis)
 }
 
 func initListen(redis asynq.RedisClientOpt) {
@@ -40,6 +43,8 @@ func initListen(redis asynq.RedisClientOpt) {
 				"low":      viper.GetInt("queue_level_low"),
 			},
 			Logger: log.Sugar,
+			// 异步回调按配置的间隔重试
+			RetryDelayFunc: handle.RetryDelayFunc,
 		},
 	)
 	mux := asynq.NewServeMux()
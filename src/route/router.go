@@ -3,6 +3,7 @@ package route
 import (
 	"github.com/assimon/luuu/controller/comm"
 	"github.com/assimon/luuu/middleware"
+	"github.com/assimon/luuu/model/mdb"
 	"github.com/labstack/echo/v4"
 	"net/http"
 )
@@ -18,10 +19,90 @@ func RegisterRoute(e *echo.Echo) {
 	payRoute.GET("/checkout-counter/:trade_id", comm.Ctrl.CheckoutCounter)
 	// 状态检测
 	payRoute.GET("/check-status/:trade_id", comm.Ctrl.CheckStatus)
+	// 选择付款网络
+	payRoute.POST("/select-channel/:trade_id", comm.Ctrl.SelectChannel)
+	// 切换付款网络
+	payRoute.POST("/switch-channel/:trade_id", comm.Ctrl.CheckoutSwitchChannel)
 
 	apiV1Route := e.Group("/api/v1")
 	// ====订单相关====
 	orderRoute := apiV1Route.Group("/order", middleware.CheckApiSign())
 	// 创建订单
-	orderRoute.POST("/create-transaction", comm.Ctrl.CreateTransaction)
+	orderRoute.POST("/create-transaction", comm.Ctrl.CreateTransaction, middleware.RequireScope(mdb.ApiKeyScopeCreate))
+	// 报价
+	orderRoute.POST("/quote", comm.Ctrl.Quote, middleware.RequireScope(mdb.ApiKeyScopeCreate))
+	// 切换付款网络
+	orderRoute.POST("/switch-channel", comm.Ctrl.SwitchChannel, middleware.RequireScope(mdb.ApiKeyScopeCreate))
+	// 取消订单
+	orderRoute.POST("/cancel", comm.Ctrl.CancelOrder, middleware.RequireScope(mdb.ApiKeyScopeCancel))
+	// 查询订单
+	orderRoute.POST("/query", comm.Ctrl.QueryOrder, middleware.RequireScope(mdb.ApiKeyScopeQuery))
+	// 回调投递记录
+	orderRoute.POST("/callback-log", comm.Ctrl.CallbackLog, middleware.RequireScope(mdb.ApiKeyScopeQuery))
+	// 手动重新回调
+	orderRoute.POST("/callback-resend", comm.Ctrl.CallbackResend, middleware.RequireScope(mdb.ApiKeyScopeAdmin))
+
+	// ====钱包相关====
+	walletRoute := apiV1Route.Group("/wallet", middleware.CheckApiSign(), middleware.RequireScope(mdb.ApiKeyScopeAdmin))
+	// 钱包列表
+	walletRoute.POST("/list", comm.Ctrl.WalletList)
+	// 添加钱包
+	walletRoute.POST("/add", comm.Ctrl.WalletAdd)
+	// 修改钱包信息
+	walletRoute.POST("/update", comm.Ctrl.WalletUpdate)
+	// 钱包最新余额
+	walletRoute.POST("/balance", comm.Ctrl.WalletBalance)
+	// 钱包余额历史
+	walletRoute.POST("/balance-history", comm.Ctrl.WalletBalanceHistory)
+
+	// ====链相关====
+	channelRoute := apiV1Route.Group("/channel", middleware.CheckApiSign(), middleware.RequireScope(mdb.ApiKeyScopeQuery))
+	// 支持的链列表
+	channelRoute.POST("/list", comm.Ctrl.ChannelList)
+
+	// ====汇率相关====
+	rateRoute := apiV1Route.Group("/rate", middleware.CheckApiSign(), middleware.RequireScope(mdb.ApiKeyScopeQuery))
+	// 当前汇率
+	rateRoute.POST("/current", comm.Ctrl.RateCurrent)
+	// 汇率历史
+	rateRoute.POST("/history", comm.Ctrl.RateHistory)
+
+	// ====定价规则====
+	pricingRuleRoute := apiV1Route.Group("/pricing-rule", middleware.CheckApiSign(), middleware.RequireScope(mdb.ApiKeyScopeAdmin))
+	// 定价规则列表
+	pricingRuleRoute.POST("/list", comm.Ctrl.PricingRuleList)
+	// 新增或修改定价规则
+	pricingRuleRoute.POST("/save", comm.Ctrl.PricingRuleSave)
+	// 删除定价规则
+	pricingRuleRoute.POST("/delete", comm.Ctrl.PricingRuleDelete)
+
+	// ====api密钥====
+	apiKeyRoute := apiV1Route.Group("/api-key", middleware.CheckApiSign(), middleware.RequireScope(mdb.ApiKeyScopeAdmin))
+	// api密钥列表
+	apiKeyRoute.POST("/list", comm.Ctrl.ApiKeyList)
+	// 新增或修改api密钥
+	apiKeyRoute.POST("/save", comm.Ctrl.ApiKeySave)
+	// 删除api密钥
+	apiKeyRoute.POST("/delete", comm.Ctrl.ApiKeyDelete)
+
+	// ====商户====
+	merchantRoute := apiV1Route.Group("/merchant", middleware.CheckApiSign(), middleware.RequirePlatform())
+	// 商户列表
+	merchantRoute.POST("/list", comm.Ctrl.MerchantList)
+	// 新增或修改商户
+	merchantRoute.POST("/save", comm.Ctrl.MerchantSave)
+
+	// ====平台手续费====
+	feeRuleRoute := apiV1Route.Group("/fee-rule", middleware.CheckApiSign(), middleware.RequirePlatform())
+	// 手续费规则列表
+	feeRuleRoute.POST("/list", comm.Ctrl.FeeRuleList)
+	// 新增或修改手续费规则
+	feeRuleRoute.POST("/save", comm.Ctrl.FeeRuleSave)
+	// 删除手续费规则
+	feeRuleRoute.POST("/delete", comm.Ctrl.FeeRuleDelete)
+	feeRoute := apiV1Route.Group("/fee", middleware.CheckApiSign(), middleware.RequireScope(mdb.ApiKeyScopeAdmin))
+	// 手续费台账
+	feeRoute.POST("/ledger", comm.Ctrl.FeeLedger)
+	// 手续费对账单
+	feeRoute.POST("/statement", comm.Ctrl.FeeStatement)
 }
@@ -0,0 +1,13 @@
+package task
+
+import (
+	"github.com/assimon/luuu/model/service"
+)
+
+// CallbackRedriveJob 定时重新投递未完成的订单回调
+type CallbackRedriveJob struct {
+}
+
+func (r CallbackRedriveJob) Run() {
+	service.RedriveOrderCallbacks()
+}
@@ -1,6 +1,11 @@
 package task
 
-import "github.com/robfig/cron/v3"
+import (
+	"fmt"
+
+	"github.com/assimon/luuu/config"
+	"github.com/robfig/cron/v3"
+)
 
 func Start() {
 	c := cron.New(
@@ -9,9 +14,13 @@ func Start() {
 			cron.SkipIfStillRunning(cron.DefaultLogger),
 		),
 	)
+	// 启动时先拉取一次汇率，避免首个周期内无汇率可用
+	go UsdtRateJob{}.Run()
 	c.AddJob("@every 60s", UsdtRateJob{})
+	c.AddJob("@every 60s", CallbackRedriveJob{})
 	c.AddJob("@every 15s", ListenTrc20Job{})
 	c.AddJob("@every 15s", ListenEvmJob{})
 	c.AddJob("@every 15s", ListenAptosJob{})
+	c.AddJob(fmt.Sprintf("@every %ds", config.GetWalletBalanceInterval()), WalletBalanceJob{})
 	c.Start()
 }
@@ -0,0 +1,34 @@
+package task
+
+import (
+	"sync"
+
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
+	"github.com/assimon/luuu/model/service"
+	"github.com/assimon/luuu/util/log"
+)
+
+type WalletBalanceJob struct {
+}
+
+var gWalletBalanceJobLock sync.Mutex
+
+func (r WalletBalanceJob) Run() {
+	gWalletBalanceJobLock.Lock()
+	defer gWalletBalanceJobLock.Unlock()
+	walletAddress, err := data.GetAllWalletAddress()
+	if err != nil {
+		log.Sugar.Error(err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, address := range walletAddress {
+		if address.Status != mdb.TokenStatusEnable {
+			continue
+		}
+		wg.Add(1)
+		go service.CheckWalletBalance(address, &wg)
+	}
+	wg.Wait()
+}
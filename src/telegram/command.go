@@ -3,7 +3,9 @@ package telegram
 import tb "gopkg.in/telebot.v3"
 
 const (
-	START_CMD = "/start"
+	START_CMD   = "/start"
+	BALANCE_CMD = "/balance"
+	ORDER_CMD   = "/order"
 )
 
 var Cmds = []tb.Command{
@@ -11,4 +13,12 @@ var Cmds = []tb.Command{
 		Text:        START_CMD,
 		Description: "开始",
 	},
+	{
+		Text:        BALANCE_CMD,
+		Description: "钱包余额",
+	},
+	{
+		Text:        ORDER_CMD,
+		Description: "订单详情及回调记录，/order epusdt订单号 或 /order 商户id 客户交易id",
+	},
 }
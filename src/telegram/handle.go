@@ -2,6 +2,8 @@ package telegram
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/assimon/luuu/model"
@@ -13,13 +15,48 @@ import (
 )
 
 const (
-	ReplayAddWallet = "请输入钱包地址, 目前仅支持 trc20 eth polygon bsc avax-c aptos arb 链。"
+	ReplayAddWallet  = "请输入钱包地址, 目前仅支持 trc20 eth polygon bsc avax-c aptos arb 链。添加到其他商户时在地址前加上商户id和空格，例如 3 eth:0x...，不加则添加到默认商户。"
+	ReplayEditWallet = "请输入钱包#%d的新%s，发送 - 清空。"
 )
 
+// walletInfoFields 可在机器人中编辑的钱包信息
+var walletInfoFields = []struct {
+	Field string
+	Name  string
+}{
+	{Field: "label", Name: "标签"},
+	{Field: "note", Name: "备注"},
+	{Field: "group_name", Name: "分组"},
+	{Field: "checkout_message", Name: "收银台提示"},
+	{Field: "weight", Name: "权重"},
+	{Field: "daily_volume_limit", Name: "每日收款上限"},
+	{Field: "daily_order_limit", Name: "每日订单上限"},
+}
+
+var replayEditWalletRegexp = regexp.MustCompile(`^请输入钱包#(\d+)的新(\S+?)，`)
+
 func OnTextMessageHandle(c tb.Context) error {
+	if c.Message().ReplyTo == nil {
+		return nil
+	}
+	if matches := replayEditWalletRegexp.FindStringSubmatch(c.Message().ReplyTo.Text); len(matches) == 3 {
+		return OnEditWalletInfo(c, mathutil.MustUint(matches[1]), matches[2])
+	}
 	if c.Message().ReplyTo.Text == ReplayAddWallet {
 		defer bots.Delete(c.Message().ReplyTo)
-		walletAddress := c.Message().Text
+		walletAddress := strings.TrimSpace(c.Message().Text)
+		var merchantId uint64 = mdb.DefaultMerchantId
+		if fields := strings.Fields(walletAddress); len(fields) == 2 {
+			merchantId = mathutil.MustUint(fields[0])
+			walletAddress = fields[1]
+			merchant, err := data.GetMerchantById(merchantId)
+			if err != nil {
+				return c.Send(err.Error())
+			}
+			if merchantId == mdb.DefaultMerchantId || merchant.ID <= 0 || merchant.Status != mdb.MerchantStatusEnable {
+				return c.Send("商户不存在或已停用！")
+			}
+		}
 		var channel = ""
 		if strings.HasPrefix(walletAddress, "T") {
 			channel = model.ChainNameTRC20
@@ -46,11 +83,11 @@ func OnTextMessageHandle(c tb.Context) error {
 		} else {
 			return c.Send("不支持该钱包地址！")
 		}
-		_, err := data.AddWalletAddress(walletAddress, channel)
+		wallet, err := data.AddWalletAddress(merchantId, walletAddress, channel)
 		if err != nil {
 			return c.Send(err.Error())
 		}
-		c.Send(fmt.Sprintf("钱包[%s]添加成功！", c.Message().Text))
+		c.Send(fmt.Sprintf("钱包[%s:%s]已添加到%s！", wallet.Channel, wallet.Token, merchantText(merchantId)))
 		return WalletList(c)
 	}
 	return nil
@@ -67,23 +104,33 @@ func WalletList(c tb.Context) error {
 	fullList.WriteString("请点击钱包继续操作\n\n")
 	fullList.WriteString("完整钱包地址列表：\n")
 
+	// 钱包按商户分组显示
+	sort.SliceStable(wallets, func(i, j int) bool {
+		return wallets[i].MerchantId < wallets[j].MerchantId
+	})
 	for i, wallet := range wallets {
+		if i == 0 || wallets[i-1].MerchantId != wallet.MerchantId {
+			fullList.WriteString(fmt.Sprintf("\n【%s】\n", merchantText(wallet.MerchantId)))
+		}
 		status := "已启用✅"
 		if wallet.Status == mdb.TokenStatusDisable {
 			status = "已禁用🚫"
 		}
 
-		// 按钮显示内容（截断）
+		// 按钮显示内容（截断），有标签时优先显示标签
 		tokenShow := wallet.Token
 		if len(wallet.Token) > 50 {
 			tokenShow = wallet.Token[:50]
 		}
+		if wallet.Label != "" || wallet.GroupName != "" {
+			tokenShow = wallet.DisplayLabel()
+		}
 
 		// --- 按钮 ---
 		var temp []tb.InlineButton
 		btnInfo := tb.InlineButton{
 			Unique: strutil.Md5(wallet.Token),
-			Text:   fmt.Sprintf("[%s] %s [%s]", wallet.Channel, tokenShow, status),
+			Text:   fmt.Sprintf("#%d [%s] %s [%s]", wallet.MerchantId, wallet.Channel, tokenShow, status),
 			Data:   strutil.MustString(wallet.ID),
 		}
 		bots.Handle(&btnInfo, WalletInfo)
@@ -93,6 +140,9 @@ func WalletList(c tb.Context) error {
 		fullList.WriteString(
 			fmt.Sprintf("%d. [%s] %s\n", i+1, wallet.Channel, wallet.Token),
 		)
+		if wallet.Label != "" || wallet.GroupName != "" {
+			fullList.WriteString(fmt.Sprintf("    %s\n", wallet.DisplayLabel()))
+		}
 	}
 
 	// 添加钱包按钮
@@ -134,20 +184,77 @@ func WalletInfo(c tb.Context) error {
 		Text:   "返回",
 		Unique: "WalletList",
 	}
+	info := fmt.Sprintf("[%s] %s\n\n标签：%s\n分组：%s\n备注：%s\n收银台提示：%s\n权重：%d\n每日收款上限：%v\n每日订单上限：%d",
+		tokenInfo.Channel, tokenInfo.Token, tokenInfo.Label, tokenInfo.GroupName, tokenInfo.Note, tokenInfo.CheckoutMessage,
+		tokenInfo.Weight, tokenInfo.DailyVolumeLimit, tokenInfo.DailyOrderLimit)
+	balance, err := data.GetLatestWalletBalanceByWalletId(tokenInfo.ID)
+	if err != nil {
+		return c.Send(err.Error())
+	}
+	if balance.ID > 0 {
+		info += fmt.Sprintf("\n\nusdt余额：%v\ngas余额：%v\n更新时间：%s",
+			balance.UsdtBalance, balance.NativeBalance, balance.CreatedAt.ToDateTimeString())
+	}
+	var editBtnList []tb.InlineButton
+	for _, item := range walletInfoFields {
+		editBtn := tb.InlineButton{
+			Text:   "修改" + item.Name,
+			Unique: "edit_" + item.Field,
+			Data:   c.Data(),
+		}
+		prompt := item.Name
+		bots.Handle(&editBtn, func(c tb.Context) error {
+			return c.Send(fmt.Sprintf(ReplayEditWallet, mathutil.MustUint(c.Data()), prompt), &tb.ReplyMarkup{
+				ForceReply: true,
+			})
+		})
+		editBtnList = append(editBtnList, editBtn)
+	}
 	bots.Handle(&enableBtn, EnableWallet)
 	bots.Handle(&disableBtn, DisableWallet)
 	bots.Handle(&delBtn, DelWallet)
 	bots.Handle(&backBtn, WalletList)
-	return c.EditOrReply(tokenInfo.Token, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{
+	keyboard := [][]tb.InlineButton{
 		{
 			enableBtn,
 			disableBtn,
 			delBtn,
 		},
-		{
-			backBtn,
-		},
-	}})
+	}
+	// 修改按钮每行两个
+	for i := 0; i < len(editBtnList); i += 2 {
+		end := i + 2
+		if end > len(editBtnList) {
+			end = len(editBtnList)
+		}
+		keyboard = append(keyboard, editBtnList[i:end])
+	}
+	keyboard = append(keyboard, []tb.InlineButton{backBtn})
+	return c.EditOrReply(info, &tb.ReplyMarkup{InlineKeyboard: keyboard})
+}
+
+// OnEditWalletInfo 处理修改钱包信息的回复
+func OnEditWalletInfo(c tb.Context, id uint64, name string) error {
+	defer bots.Delete(c.Message().ReplyTo)
+	field := ""
+	for _, item := range walletInfoFields {
+		if item.Name == name {
+			field = item.Field
+		}
+	}
+	if id <= 0 || field == "" {
+		return c.Send("请求不合法！")
+	}
+	value := strings.TrimSpace(c.Message().Text)
+	if value == "-" {
+		value = ""
+	}
+	err := data.UpdateWalletAddressInfo(id, map[string]string{field: value})
+	if err != nil {
+		return c.Send(err.Error())
+	}
+	c.Send(fmt.Sprintf("钱包#%d的%s修改成功！", id, name))
+	return WalletList(c)
 }
 
 func EnableWallet(c tb.Context) error {
@@ -185,3 +292,137 @@ func DelWallet(c tb.Context) error {
 	}
 	return WalletList(c)
 }
+
+func WalletBalanceList(c tb.Context) error {
+	balances, err := data.GetLatestWalletBalances()
+	if err != nil {
+		return c.Send(err.Error())
+	}
+	if len(balances) == 0 {
+		return c.Send("暂无钱包余额数据，请稍后再试。")
+	}
+	var msg strings.Builder
+	msg.WriteString("钱包余额：\n\n")
+	for i, balance := range balances {
+		msg.WriteString(fmt.Sprintf("%d. %s\nusdt：%v  gas：%v\n更新时间：%s\n\n",
+			i+1, balance.TokenWithChainPrefix, balance.UsdtBalance, balance.NativeBalance, balance.CreatedAt.ToDateTimeString()))
+	}
+	return c.Send(msg.String())
+}
+
+// OrderDetail 订单详情及最近的回调记录，支持epusdt订单号或客户交易id
+func OrderDetail(c tb.Context) error {
+	var (
+		order *mdb.Orders
+		err   error
+	)
+	// 客户交易id仅在商户内唯一，需同时指定商户id
+	switch len(c.Args()) {
+	case 1:
+		order, err = data.GetOrderInfoByTradeId(c.Args()[0])
+	case 2:
+		order, err = data.GetMerchantOrderByOrderId(mathutil.MustUint(c.Args()[0]), c.Args()[1])
+	default:
+		return c.Send("请输入订单号，例如：/order epusdt订单号 或 /order 商户id 客户交易id")
+	}
+	if err != nil {
+		return c.Send(err.Error())
+	}
+	if order.ID <= 0 {
+		return c.Send("订单不存在！")
+	}
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("商户：%s\n", merchantText(order.MerchantId)))
+	msg.WriteString(fmt.Sprintf("epusdt订单号：%s\n客户交易id：%s\n状态：%s\n金额：%v %s\n实际支付：%v USDT\n收款钱包：%s\n区块交易号：%s\n回调次数：%d\n回调确认：%s\n创建时间：%s\n",
+		order.TradeId, order.OrderId, orderStatusText(order.Status), order.Amount, strings.ToUpper(order.Currency), order.ActualAmount,
+		order.TokenWithChainPrefix, order.BlockTransactionId, order.CallbackNum, callbackConfirmText(order.CallBackConfirm), order.CreatedAt.ToDateTimeString()))
+	if order.CustomerName != "" || order.CustomerEmail != "" {
+		msg.WriteString(fmt.Sprintf("客户：%s %s\n", order.CustomerName, order.CustomerEmail))
+	}
+	if order.Metadata != "" {
+		msg.WriteString(fmt.Sprintf("自定义数据：%s\n", strutil.Substr(order.Metadata, 0, 200)))
+	}
+	callbackLogs, err := data.GetLatestCallbackLogs(order.TradeId, 5)
+	if err != nil {
+		return c.Send(err.Error())
+	}
+	if len(callbackLogs) > 0 {
+		msg.WriteString("\n最近回调记录：\n")
+	}
+	for _, callbackLog := range callbackLogs {
+		result := "成功"
+		if callbackLog.Status != mdb.CallbackLogStatusSuccess {
+			result = "失败"
+		}
+		msg.WriteString(fmt.Sprintf("\n#%d %s %s %s\n状态码：%d  耗时：%dms\n", callbackLog.Attempt, callbackLog.EventType, result, callbackLog.CreatedAt.ToDateTimeString(), callbackLog.StatusCode, callbackLog.Duration))
+		if callbackLog.Error != "" {
+			msg.WriteString(fmt.Sprintf("错误：%s\n", strutil.Substr(callbackLog.Error, 0, 200)))
+		}
+		if callbackLog.ResponseBody != "" {
+			msg.WriteString(fmt.Sprintf("响应：%s\n", strutil.Substr(callbackLog.ResponseBody, 0, 200)))
+		}
+	}
+	if order.CallbackEvent() == "" {
+		return c.Send(msg.String())
+	}
+	resendBtn := tb.InlineButton{
+		Text:   "重新回调",
+		Unique: "resendCallbackBtn",
+		Data:   order.TradeId,
+	}
+	bots.Handle(&resendBtn, ResendCallback)
+	return c.Send(msg.String(), &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{{resendBtn}},
+	})
+}
+
+// ResendCallback 重新回调订单，操作人记录为机器人用户
+func ResendCallback(c tb.Context) error {
+	if OrderCallbackResender == nil {
+		return c.Send("重新回调不可用！")
+	}
+	operator := strutil.MustString(c.Sender().ID)
+	if c.Sender().Username != "" {
+		operator = c.Sender().Username
+	}
+	if err := OrderCallbackResender(c.Data(), operator, mdb.CallbackResendSourceTelegram); err != nil {
+		return c.Send(err.Error())
+	}
+	return c.Send(fmt.Sprintf("订单[%s]已重新投递回调，可稍后发送 /order %s 查看回调结果。", c.Data(), c.Data()))
+}
+
+func orderStatusText(status int) string {
+	switch status {
+	case mdb.StatusWaitPay:
+		return "待支付"
+	case mdb.StatusPaySuccess:
+		return "已支付"
+	case mdb.StatusExpired:
+		return "已过期"
+	case mdb.StatusCancelled:
+		return "已取消"
+	}
+	return fmt.Sprintf("%d", status)
+}
+
+func callbackConfirmText(confirm int) string {
+	switch confirm {
+	case mdb.CallBackConfirmOk:
+		return "是"
+	case mdb.CallBackConfirmNo:
+		return "否"
+	}
+	return "-"
+}
+
+// merchantText 商户显示名称
+func merchantText(merchantId uint64) string {
+	if merchantId == mdb.DefaultMerchantId {
+		return "默认商户#0"
+	}
+	merchant, err := data.GetMerchantById(merchantId)
+	if err != nil || merchant.ID <= 0 {
+		return fmt.Sprintf("商户#%d", merchantId)
+	}
+	return fmt.Sprintf("%s#%d", merchant.Name, merchantId)
+}
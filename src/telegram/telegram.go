@@ -2,6 +2,8 @@ package telegram
 
 import (
 	"github.com/assimon/luuu/config"
+	"github.com/assimon/luuu/model/data"
+	"github.com/assimon/luuu/model/mdb"
 	"github.com/assimon/luuu/util/log"
 	tb "gopkg.in/telebot.v3"
 	"gopkg.in/telebot.v3/middleware"
@@ -10,6 +12,9 @@ import (
 
 var bots *tb.Bot
 
+// OrderCallbackResender 重新回调订单，由服务层注入以避免循环引用
+var OrderCallbackResender func(tradeId, operator, source string) error
+
 // BotStart 机器人启动
 func BotStart() {
 	var err error
@@ -39,20 +44,44 @@ func RegisterHandle() {
 	adminOnly := bots.Group()
 	adminOnly.Use(middleware.Whitelist(config.TgManage))
 	adminOnly.Handle(START_CMD, WalletList)
+	adminOnly.Handle(BALANCE_CMD, WalletBalanceList)
+	adminOnly.Handle(ORDER_CMD, OrderDetail)
 	adminOnly.Handle(tb.OnText, OnTextMessageHandle)
 }
 
 // SendToBot 主动发送消息机器人消息
 func SendToBot(msg string) {
-	go func() {
-		user := tb.User{
-			ID: config.TgManage,
-		}
-		_, err := bots.Send(&user, msg, &tb.SendOptions{
-			ParseMode: tb.ModeHTML,
-		})
+	sendToChats([]int64{config.TgManage}, msg)
+}
+
+// SendToMerchant 发送商户相关的消息，管理员与商户配置的telegram会话均会收到
+func SendToMerchant(merchantId uint64, msg string) {
+	chatIds := []int64{config.TgManage}
+	if merchantId != mdb.DefaultMerchantId {
+		merchant, err := data.GetMerchantById(merchantId)
 		if err != nil {
 			log.Sugar.Error(err)
 		}
+		if merchant != nil && merchant.ID > 0 {
+			for _, chatId := range merchant.GetTgChatIds() {
+				if chatId != config.TgManage {
+					chatIds = append(chatIds, chatId)
+				}
+			}
+		}
+	}
+	sendToChats(chatIds, msg)
+}
+
+func sendToChats(chatIds []int64, msg string) {
+	go func() {
+		for _, chatId := range chatIds {
+			_, err := bots.Send(tb.ChatID(chatId), msg, &tb.SendOptions{
+				ParseMode: tb.ModeHTML,
+			})
+			if err != nil {
+				log.Sugar.Error(err)
+			}
+		}
 	}()
 }
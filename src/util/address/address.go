@@ -0,0 +1,103 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/assimon/luuu/util/constant"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	tronAddressVersion = 0x41 // tron主网地址版本字节
+	tronAddressLength  = 25   // 版本(1) + 地址(20) + 校验和(4)
+	base58Alphabet     = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+// ValidateTronAddress 校验tron base58check地址
+func ValidateTronAddress(address string) error {
+	if !strings.HasPrefix(address, "T") {
+		return constant.TronAddressFormatErr
+	}
+	decoded, err := base58Decode(address)
+	if err != nil || len(decoded) != tronAddressLength || decoded[0] != tronAddressVersion {
+		return constant.TronAddressFormatErr
+	}
+	payload, checksum := decoded[:21], decoded[21:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return constant.TronAddressChecksumErr
+	}
+	return nil
+}
+
+// NormalizeEvmAddress 校验evm地址并转换为 EIP-55 校验和格式
+// 全小写或全大写的地址视为未带校验和，大小写混合的地址必须通过 EIP-55 校验
+func NormalizeEvmAddress(address string) (string, error) {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return "", constant.EvmAddressFormatErr
+	}
+	hexPart := address[2:]
+	if _, err := hex.DecodeString(hexPart); err != nil {
+		return "", constant.EvmAddressFormatErr
+	}
+	checksumAddress := ToEvmChecksumAddress(hexPart)
+	lower, upper := strings.ToLower(hexPart), strings.ToUpper(hexPart)
+	if hexPart != lower && hexPart != upper && address != checksumAddress {
+		return "", constant.EvmAddressChecksumErr
+	}
+	return checksumAddress, nil
+}
+
+// ToEvmChecksumAddress 将40位十六进制地址转换为 EIP-55 校验和格式
+func ToEvmChecksumAddress(hexPart string) string {
+	lower := strings.ToLower(strings.TrimPrefix(hexPart, "0x"))
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	digest := hex.EncodeToString(hash.Sum(nil))
+	result := []byte(lower)
+	for i, c := range result {
+		if c >= 'a' && c <= 'f' && digest[i] >= '8' {
+			result[i] = c - 32
+		}
+	}
+	return "0x" + string(result)
+}
+
+// NormalizeAptosAddress 校验aptos地址(32字节十六进制)并统一为小写
+func NormalizeAptosAddress(address string) (string, error) {
+	if len(address) != 66 || !strings.HasPrefix(address, "0x") {
+		return "", constant.AptosAddressFormatErr
+	}
+	lower := strings.ToLower(address)
+	if _, err := hex.DecodeString(lower[2:]); err != nil {
+		return "", constant.AptosAddressFormatErr
+	}
+	return lower, nil
+}
+
+// base58Decode base58解码
+func base58Decode(input string) ([]byte, error) {
+	result := big.NewInt(0)
+	radix := big.NewInt(58)
+	for _, c := range input {
+		index := strings.IndexRune(base58Alphabet, c)
+		if index < 0 {
+			return nil, errors.New("invalid base58 character")
+		}
+		result.Mul(result, radix)
+		result.Add(result, big.NewInt(int64(index)))
+	}
+	decoded := result.Bytes()
+	// 前导的 1 对应前导零字节
+	leadingZeros := 0
+	for leadingZeros < len(input) && input[leadingZeros] == base58Alphabet[0] {
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), decoded...), nil
+}
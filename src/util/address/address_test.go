@@ -0,0 +1,88 @@
+package address
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/assimon/luuu/util/constant"
+)
+
+func TestValidateTronAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		err     error
+	}{
+		{"valid", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", nil},
+		{"bad checksum", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", constant.TronAddressChecksumErr},
+		{"no T prefix", "R7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", constant.TronAddressFormatErr},
+		{"invalid base58", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj60", constant.TronAddressFormatErr},
+		{"too short", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj", constant.TronAddressFormatErr},
+		{"empty", "", constant.TronAddressFormatErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTronAddress(tt.address); !errors.Is(err, tt.err) {
+				t.Errorf("ValidateTronAddress(%q) = %v, want %v", tt.address, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNormalizeEvmAddress(t *testing.T) {
+	// EIP-55 规范中的测试向量
+	tests := []struct {
+		name    string
+		address string
+		want    string
+		err     error
+	}{
+		{"checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", nil},
+		{"checksum 2", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", nil},
+		{"all lower", "0xdbf03b407c01e7cd3cbea99509d93f8dddc8c6fb", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", nil},
+		{"all upper", "0xD1220A0CF47C7B9BE7A2E6BA89F429762E7B9ADB", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", nil},
+		{"bad checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "", constant.EvmAddressChecksumErr},
+		{"no 0x prefix", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", "", constant.EvmAddressFormatErr},
+		{"not hex", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", "", constant.EvmAddressFormatErr},
+		{"too short", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", "", constant.EvmAddressFormatErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeEvmAddress(tt.address)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("NormalizeEvmAddress(%q) err = %v, want %v", tt.address, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeEvmAddress(%q) = %s, want %s", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAptosAddress(t *testing.T) {
+	lower := "0x" + strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		address string
+		want    string
+		err     error
+	}{
+		{"lower", lower, lower, nil},
+		{"upper", "0x" + strings.Repeat("AB", 32), lower, nil},
+		{"no 0x prefix", strings.Repeat("ab", 33), "", constant.AptosAddressFormatErr},
+		{"not hex", "0x" + strings.Repeat("zz", 32), "", constant.AptosAddressFormatErr},
+		{"short", "0x1", "", constant.AptosAddressFormatErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeAptosAddress(tt.address)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("NormalizeAptosAddress(%q) err = %v, want %v", tt.address, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeAptosAddress(%q) = %s, want %s", tt.address, got, tt.want)
+			}
+		})
+	}
+}
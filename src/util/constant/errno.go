@@ -12,6 +12,38 @@ var Errno = map[int]string{
 	10007: "订单区块已处理",
 	10008: "订单不存在",
 	10009: "无法解析请求参数",
+	10010: "不支持该钱包网络",
+	10011: "tron钱包地址格式有误，应为以 T 开头的 base58 地址",
+	10012: "tron钱包地址校验失败，请检查地址是否输入有误",
+	10013: "evm钱包地址格式有误，应为 0x 开头的 40 位十六进制字符",
+	10014: "evm钱包地址 EIP-55 校验失败，请检查地址大小写是否输入有误",
+	10015: "aptos钱包地址格式有误，应为 0x 开头的 64 位十六进制字符",
+	10016: "可用钱包均已达到每日收款上限",
+	10017: "钱包不存在",
+	10018: "钱包信息过长",
+	10019: "汇率已过期或暂不可用，暂时无法创建订单",
+	10020: "不支持该法币",
+	10021: "订单金额不在允许范围内",
+	10022: "定价规则不存在",
+	10023: "报价不存在、已过期或已使用",
+	10024: "订单金额、法币或链与报价不一致",
+	10025: "订单已选择付款网络",
+	10026: "订单已是该付款网络",
+	10027: "订单未支付或未开启该状态的回调，无法回调",
+	10028: "请指定订单号或开始时间",
+	10029: "不支持的回调事件",
+	10030: "订单不是待支付状态，无法取消",
+	10031: "签名已过期，请检查时间戳",
+	10032: "请求已处理，请勿重复提交",
+	10033: "api密钥不存在、已停用或已过期",
+	10034: "api密钥无权访问该接口",
+	10035: "不支持的api密钥权限",
+	10036: "商户不存在或已停用",
+	10037: "异步回调网址不能为空",
+	10038: "手续费规则不存在",
+	10039: "metadata须为json对象且不超过2048字节",
+	10040: "钱包权重与每日上限须为不小于0的数字",
+	10041: "订单不是待支付状态，无法标记为支付成功",
 }
 
 var (
@@ -26,6 +58,38 @@ var (
 	OrderBlockAlreadyProcess   = Err(10007)
 	OrderNotExists             = Err(10008)
 	ParamsMarshalErr           = Err(10009)
+	ChannelNotSupportErr       = Err(10010)
+	TronAddressFormatErr       = Err(10011)
+	TronAddressChecksumErr     = Err(10012)
+	EvmAddressFormatErr        = Err(10013)
+	EvmAddressChecksumErr      = Err(10014)
+	AptosAddressFormatErr      = Err(10015)
+	WalletDailyLimitErr        = Err(10016)
+	WalletAddressNotExists     = Err(10017)
+	WalletInfoTooLongErr       = Err(10018)
+	RateStaleErr               = Err(10019)
+	CurrencyNotSupportErr      = Err(10020)
+	PricingAmountRangeErr      = Err(10021)
+	PricingRuleNotExists       = Err(10022)
+	QuoteNotExists             = Err(10023)
+	QuoteMismatchErr           = Err(10024)
+	OrderChannelSelectedErr    = Err(10025)
+	OrderChannelSameErr        = Err(10026)
+	OrderNotPaidErr            = Err(10027)
+	CallbackResendParamsErr    = Err(10028)
+	NotifyEventNotSupportErr   = Err(10029)
+	OrderCancelErr             = Err(10030)
+	SignatureExpiredErr        = Err(10031)
+	SignatureNonceErr          = Err(10032)
+	ApiKeyNotExists            = Err(10033)
+	ApiKeyScopeErr             = Err(10034)
+	ApiKeyScopeNotSupportErr   = Err(10035)
+	MerchantNotExists          = Err(10036)
+	NotifyUrlEmptyErr          = Err(10037)
+	FeeRuleNotExists           = Err(10038)
+	OrderMetadataErr           = Err(10039)
+	WalletInfoNumberErr        = Err(10040)
+	OrderNotWaitPayErr         = Err(10041)
 )
 
 type RspError struct {
@@ -3,3 +3,11 @@ package json
 import jsoniter "github.com/json-iterator/go"
 
 var Cjson = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// CjsonNumber 数字解析为 json.Number 以保留原始精度，用于签名
+var CjsonNumber = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+	UseNumber:              true,
+}.Froze()
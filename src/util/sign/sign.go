@@ -1,34 +1,37 @@
 package sign
 
 import (
+	stdjson "encoding/json"
 	"errors"
-	"github.com/assimon/luuu/util/json"
-	"github.com/gookit/goutil/strutil"
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/assimon/luuu/util/json"
+	"github.com/gookit/goutil/strutil"
+	"github.com/shopspring/decimal"
 )
 
 // Get 获取签名
 func Get(data interface{}, bizKey string) (string, error) {
-	var err error
-	signStr := ""
+	signStr, err := Params(data)
+	if err != nil {
+		return "", err
+	}
+	sign := strutil.Md5(signStr + bizKey)
+	return sign, nil
+}
+
+// Params 获取待签名的参数字符串，参数按名称排序，空值与 signature 不参与签名
+func Params(data interface{}) (string, error) {
 	switch v := reflect.ValueOf(data); v.Kind() {
 	case reflect.Map:
-		signStr, err = MapToParams(data.(map[string]interface{}))
-		if err != nil {
-			return "", err
-		}
+		return MapToParams(data.(map[string]interface{}))
 	case reflect.Struct:
-		signStr, err = Struct2map(v.Interface())
-		if err != nil {
-			return "", err
-		}
-	default:
-		return "", errors.New("type err")
+		return Struct2map(v.Interface())
 	}
-	sign := strutil.Md5(signStr + bizKey)
-	return sign, nil
+	return "", errors.New("type err")
 }
 
 func Struct2map(content interface{}) (string, error) {
@@ -37,81 +40,110 @@ func Struct2map(content interface{}) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if err = json.Cjson.Unmarshal(marshal, &params); err != nil {
+	if err = json.CjsonNumber.Unmarshal(marshal, &params); err != nil {
 		return "", err
 	}
 	paramsUrl, err := MapToParams(params)
 	return paramsUrl, err
 }
 
+// MapToParams 规范化参数并按参数名排序，使用 key1=value1&key2=value2 拼接
+// 嵌套对象与数组展开为 key[sub]=value、key[0]=value，空值、空对象、空数组不参与签名
 func MapToParams(params map[string]interface{}) (string, error) {
+	flattened, err := FlattenParams(params)
+	if err != nil {
+		return "", err
+	}
 	var tempArr []string
-	temString := ""
-	for k, v := range params {
-		if k == "signature" {
+	for k, v := range flattened {
+		// 空值不参与签名
+		if k == "signature" || v == "" {
 			continue
 		}
-		if v == nil {
-			continue
+		tempArr = append(tempArr, k+"="+v)
+	}
+	sort.Strings(tempArr)
+	return strings.Join(tempArr, "&"), nil
+}
+
+// FlattenParams 将嵌套对象与数组展开为 key[sub]、key[0] 形式的参数，null 被忽略
+func FlattenParams(params map[string]interface{}) (map[string]string, error) {
+	flattened := make(map[string]string)
+	for k, v := range params {
+		if err := flattenParam(flattened, k, v); err != nil {
+			return nil, err
 		}
-		fv := ""
-		switch v.(type) {
-		case float64:
-			ft := v.(float64)
-			fv = strconv.FormatFloat(ft, 'f', -1, 64)
-		case float32:
-			ft := v.(float32)
-			fv = strconv.FormatFloat(float64(ft), 'f', -1, 64)
-		case int:
-			it := v.(int)
-			fv = strconv.Itoa(it)
-		case uint:
-			it := v.(uint)
-			fv = strconv.Itoa(int(it))
-		case int8:
-			it := v.(int8)
-			fv = strconv.Itoa(int(it))
-		case uint8:
-			it := v.(uint8)
-			fv = strconv.Itoa(int(it))
-		case int16:
-			it := v.(int16)
-			fv = strconv.Itoa(int(it))
-		case uint16:
-			it := v.(uint16)
-			fv = strconv.Itoa(int(it))
-		case int32:
-			it := v.(int32)
-			fv = strconv.Itoa(int(it))
-		case uint32:
-			it := v.(uint32)
-			fv = strconv.Itoa(int(it))
-		case int64:
-			it := v.(int64)
-			fv = strconv.FormatInt(it, 10)
-		case uint64:
-			it := v.(uint64)
-			fv = strconv.FormatUint(it, 10)
-		case string:
-			fv = v.(string)
-		case []byte:
-			fv = string(v.([]byte))
-		default:
-			return "", errors.New("signature marshal error")
+	}
+	return flattened, nil
+}
+
+func flattenParam(flattened map[string]string, key string, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for subKey, subValue := range v {
+			if err := flattenParam(flattened, key+"["+subKey+"]", subValue); err != nil {
+				return err
+			}
 		}
-		// 空值不参与签名
-		if fv == "" {
-			continue
+		return nil
+	case []interface{}:
+		for i, item := range v {
+			if err := flattenParam(flattened, key+"["+strconv.Itoa(i)+"]", item); err != nil {
+				return err
+			}
 		}
-		tempArr = append(tempArr, k+"="+fv)
+		return nil
 	}
-	sort.Strings(tempArr)
-	for n, v := range tempArr {
-		if n+1 < len(tempArr) {
-			temString = temString + v + "&"
-		} else {
-			temString = temString + v
+	fv, err := FormatValue(value)
+	if err != nil {
+		return err
+	}
+	flattened[key] = fv
+	return nil
+}
+
+// FormatValue 标量参数的规范格式
+// 数字使用不带指数与末尾0的十进制表示，例如 1.50 -> 1.5、1e3 -> 1000、-0 -> 0；布尔值为 true false
+func FormatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case stdjson.Number:
+		d, err := decimal.NewFromString(v.String())
+		if err != nil {
+			return "", err
 		}
+		return d.String(), nil
+	case float64:
+		return decimal.NewFromFloat(v).String(), nil
+	case float32:
+		return decimal.NewFromFloat32(v).String(), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
 	}
-	return temString, nil
+	return "", errors.New("signature marshal error")
 }
@@ -0,0 +1,140 @@
+package sign
+
+import (
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/assimon/luuu/util/json"
+)
+
+// 与 wiki/API.md 「嵌套参数与数字格式」中的测试向量保持一致，修改签名规则时需同步更新文档
+const (
+	vectorKey       = "epusdt_password_xasddawqe"
+	vectorMethod    = "POST"
+	vectorPath      = "/api/v1/order/create-transaction"
+	vectorTimestamp = "1760832000"
+	vectorNonce     = "6f1c2a9b8d7e4f30"
+)
+
+var signVectors = []struct {
+	name   string
+	body   string
+	params string
+	md5    string
+	v2     string
+}{
+	{
+		name:   "basic",
+		body:   `{"order_id":"20220201030210321","amount":42,"notify_url":"http://example.com/notify","redirect_url":"http://example.com/redirect"}`,
+		params: "amount=42&notify_url=http://example.com/notify&order_id=20220201030210321&redirect_url=http://example.com/redirect",
+		md5:    "1cd4b52df5587cfb1968b0c0c6e156cd",
+		v2:     "61a257303ff92dcb19094f2fae525df5ae16493cda3ae0841b2128663cdc7f08",
+	},
+	{
+		name:   "number format",
+		body:   `{"amount":1.50,"big":12345678901234567890,"exp":1e3,"neg":-0.0,"small":0.000001,"flag":true,"empty":"","nil":null}`,
+		params: "amount=1.5&big=12345678901234567890&exp=1000&flag=true&neg=0&small=0.000001",
+		md5:    "2e13ed6dd126603f3af93bb32ccaa11f",
+		v2:     "1f2362d0c5cc10894e545facbafa09b912a3cd40b52b3338dacfe292235b9bbd",
+	},
+	{
+		name:   "nested",
+		body:   `{"order_id":"A1","metadata":{"user":{"id":7,"name":"tom"},"tags":["vip","new"],"empty":{}},"items":[{"sku":"s1","qty":2,"price":9.9},{"sku":"s2","qty":1,"price":10}]}`,
+		params: "items[0][price]=9.9&items[0][qty]=2&items[0][sku]=s1&items[1][price]=10&items[1][qty]=1&items[1][sku]=s2&metadata[tags][0]=vip&metadata[tags][1]=new&metadata[user][id]=7&metadata[user][name]=tom&order_id=A1",
+		md5:    "e7ed83e7ed3401039e8ce980d7bc4d02",
+		v2:     "d4e853610cd63a08448d77146012e095290bae4761f2d5b870808f557bb5f581",
+	},
+}
+
+func TestSignVectors(t *testing.T) {
+	for _, tt := range signVectors {
+		t.Run(tt.name, func(t *testing.T) {
+			var m map[string]interface{}
+			if err := json.CjsonNumber.Unmarshal([]byte(tt.body), &m); err != nil {
+				t.Fatal(err)
+			}
+			params, err := Params(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params != tt.params {
+				t.Errorf("params = %q, want %q", params, tt.params)
+			}
+			md5, err := Get(m, vectorKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if md5 != tt.md5 {
+				t.Errorf("md5 = %s, want %s", md5, tt.md5)
+			}
+			if v2 := HmacSha256(vectorMethod, vectorPath, vectorTimestamp, vectorNonce, params, vectorKey); v2 != tt.v2 {
+				t.Errorf("v2 = %s, want %s", v2, tt.v2)
+			}
+		})
+	}
+}
+
+func TestV2BindsMethodAndPath(t *testing.T) {
+	params := signVectors[0].params
+	want := HmacSha256(vectorMethod, vectorPath, vectorTimestamp, vectorNonce, params, vectorKey)
+	if got := HmacSha256(vectorMethod, "/api/v1/order/quote", vectorTimestamp, vectorNonce, params, vectorKey); got == want {
+		t.Error("signature must differ for another path")
+	}
+	if got := HmacSha256("GET", vectorPath, vectorTimestamp, vectorNonce, params, vectorKey); got == want {
+		t.Error("signature must differ for another method")
+	}
+	if got := HmacSha256("post", vectorPath, vectorTimestamp, vectorNonce, params, vectorKey); got != want {
+		t.Error("method must be case insensitive")
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{stdjson.Number("1.50"), "1.5"},
+		{stdjson.Number("1e3"), "1000"},
+		{stdjson.Number("-0.0"), "0"},
+		{stdjson.Number("0.000001"), "0.000001"},
+		{stdjson.Number("12345678901234567890"), "12345678901234567890"},
+		{1.5, "1.5"},
+		{float64(100), "100"},
+		{uint64(18446744073709551615), "18446744073709551615"},
+		{true, "true"},
+		{"a b", "a b"},
+	}
+	for _, tt := range tests {
+		got, err := FormatValue(tt.value)
+		if err != nil {
+			t.Fatalf("FormatValue(%v): %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("FormatValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFlattenParams(t *testing.T) {
+	var m map[string]interface{}
+	body := `{"a":{"b":[{"c":1},null,"x"],"empty":[]},"n":null}`
+	if err := json.CjsonNumber.Unmarshal([]byte(body), &m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := FlattenParams(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"a[b][0][c]": "1",
+		"a[b][2]":    "x",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("FlattenParams = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
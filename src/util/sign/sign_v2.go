@@ -0,0 +1,78 @@
+package sign
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// v2 签名请求头
+const (
+	HeaderTimestamp = "X-Epusdt-Timestamp"
+	HeaderNonce     = "X-Epusdt-Nonce"
+	HeaderSignature = "X-Epusdt-Signature"
+	HeaderKeyId     = "X-Epusdt-Key-Id"
+
+	NonceMinLength = 8
+	NonceMaxLength = 64
+)
+
+// V2 v2签名信息
+type V2 struct {
+	Timestamp string
+	Nonce     string
+	Signature string
+}
+
+// CanonicalString v2待签名字符串：请求方法(大写)、请求路径、时间戳、随机串与参数字符串以换行连接
+// 请求方法与路径参与签名，避免签名被重放到参数相同的其他接口
+func CanonicalString(method, path, timestamp, nonce, params string) string {
+	if path == "" {
+		path = "/"
+	}
+	return strings.ToUpper(method) + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + params
+}
+
+// HmacSha256 v2签名，HMAC-SHA256(key, 待签名字符串) 的小写十六进制
+func HmacSha256(method, path, timestamp, nonce, params, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(CanonicalString(method, path, timestamp, nonce, params)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetV2 使用当前时间与新的随机串生成v2签名
+func GetV2(method, path string, data interface{}, key string) (*V2, error) {
+	params, err := Params(data)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := NewNonce()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return &V2{
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Signature: HmacSha256(method, path, timestamp, nonce, params, key),
+	}, nil
+}
+
+// NewNonce 生成32位随机串
+func NewNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Equal 常量时间比较签名，避免时序攻击
+func Equal(signature, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) == 1
+}